@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/adamararcane/gator/internal/htmltext"
+	"golang.org/x/term"
+)
+
+func handlerRemoveFeed(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: usage: removefeed <url>")
+	}
+
+	feed, err := s.db.GetFeed(context.Background(), cmd.args[0])
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("error: no feed with url '%s'", cmd.args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("error getting feed: %w", err)
+	}
+
+	if feed.UserID != user.ID && !user.IsAdmin {
+		return fmt.Errorf("error: only the user who added '%s' or an admin can remove it", feed.Name)
+	}
+
+	// Posts, read state and every user's follow of the feed cascade with it.
+	if err := s.db.DeleteFeed(context.Background(), feed.ID); err != nil {
+		return fmt.Errorf("error removing feed: %w", err)
+	}
+
+	fmt.Printf("Removed feed %s (%s)\n", htmltext.Line(feed.Name), htmltext.Line(feed.Url))
+	return nil
+}
+
+func handlerDeleteUser(s *state, cmd command, user database.User) error {
+	args, yes := splitYesFlag(cmd.args)
+	if len(args) != 1 {
+		return fmt.Errorf("error: usage: deleteuser <name> [--yes]")
+	}
+	name := args[0]
+
+	target, err := s.db.GetUser(context.Background(), name)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("error: username '%s' does not exist", name)
+	}
+	if err != nil {
+		return fmt.Errorf("error retrieving user: %w", err)
+	}
+
+	if err := checkCanDeleteUser(s, user, target); err != nil {
+		return err
+	}
+
+	feedCount, err := s.db.CountFeedsForUser(context.Background(), target.ID)
+	if err != nil {
+		return fmt.Errorf("error counting feeds: %w", err)
+	}
+
+	if !yes {
+		prompt := fmt.Sprintf("Delete user '%s'?", target.Name)
+		if feedCount > 0 {
+			prompt = fmt.Sprintf("Delete user '%s' and the %d feed(s) they added, with all their posts?", target.Name, feedCount)
+		}
+		ok, err := confirm(prompt)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Nothing deleted")
+			return nil
+		}
+	}
+
+	if err := s.db.DeleteUser(context.Background(), target.ID); err != nil {
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+
+	// Don't leave the config logged in as a user that no longer exists.
+	if s.cfg.Current_user_name == target.Name {
+		if err := s.cfg.SetUser(""); err != nil {
+			return fmt.Errorf("error logging out deleted user: %w", err)
+		}
+	}
+
+	fmt.Printf("Deleted user %s\n", target.Name)
+	return nil
+}
+
+func handlerPromote(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: usage: promote <name>")
+	}
+	if !user.IsAdmin {
+		return fmt.Errorf("error: only an admin can promote users")
+	}
+
+	target, err := s.db.GetUser(context.Background(), cmd.args[0])
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("error: username '%s' does not exist", cmd.args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("error retrieving user: %w", err)
+	}
+
+	if err := s.db.PromoteUser(context.Background(), target.ID); err != nil {
+		return fmt.Errorf("error promoting user: %w", err)
+	}
+
+	fmt.Printf("%s is now an admin\n", target.Name)
+	return nil
+}
+
+// ===== Helper Functions =====
+
+// checkCanDeleteUser lets users delete themselves and admins delete anyone,
+// as long as an admin is left to manage the remaining users.
+func checkCanDeleteUser(s *state, user, target database.User) error {
+	if target.ID != user.ID && !user.IsAdmin {
+		return fmt.Errorf("error: only an admin can delete other users")
+	}
+
+	if target.IsAdmin {
+		admins, err := s.db.CountAdmins(context.Background())
+		if err != nil {
+			return fmt.Errorf("error counting admins: %w", err)
+		}
+		users, err := s.db.GetUsers(context.Background())
+		if err != nil {
+			return fmt.Errorf("error retrieving users: %w", err)
+		}
+		if admins <= 1 && len(users) > 1 {
+			return fmt.Errorf("error: '%s' is the last admin, promote another user first", target.Name)
+		}
+	}
+	return nil
+}
+
+// checkIsAdmin returns an error unless the logged-in user is an admin.
+// Without any users there is nothing to protect, so a fresh database needs
+// no login.
+func checkIsAdmin(s *state, action string) error {
+	users, err := s.db.GetUsers(context.Background())
+	if err != nil {
+		return fmt.Errorf("error retrieving users: %w", err)
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	user, err := s.db.GetUser(context.Background(), s.cfg.Current_user_name)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("error: only an admin can %s, log in as one first", action)
+	}
+	if err != nil {
+		return fmt.Errorf("error logging in user: %w", err)
+	}
+	if !user.IsAdmin {
+		return fmt.Errorf("error: only an admin can %s", action)
+	}
+	return nil
+}
+
+// splitYesFlag removes --yes (or -y) from args, reporting whether it was
+// given.
+func splitYesFlag(args []string) ([]string, bool) {
+	var rest []string
+	yes := false
+	for _, arg := range args {
+		if arg == "--yes" || arg == "-y" {
+			yes = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, yes
+}
+
+// confirm asks a yes/no question on the terminal. Without a terminal to ask
+// on it refuses, so scripts have to pass --yes explicitly.
+func confirm(prompt string) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, fmt.Errorf("error: refusing to delete without confirmation, pass --yes to skip the prompt")
+	}
+
+	fmt.Printf("%s [y/N] ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return false, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/adamararcane/gator/internal/config"
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/adamararcane/gator/internal/htmltext"
+	"github.com/adamararcane/gator/internal/secret"
+	"golang.org/x/term"
+)
+
+// feedAuth holds the credentials of a private feed. It is stored in
+// feeds.auth as JSON encrypted with the secret key, bound to the feed's ID.
+type feedAuth struct {
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+	Cookie   string `json:"cookie,omitempty"`
+}
+
+// apply adds the credentials to a request for the feed. The HTTP client
+// drops them if the feed redirects to another domain.
+func (a *feedAuth) apply(req *http.Request) {
+	switch a.Type {
+	case "basic":
+		req.SetBasicAuth(a.Username, a.Password)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	case "cookie":
+		req.Header.Set("Cookie", a.Cookie)
+	}
+}
+
+func handlerFeedAuth(s *state, cmd command, user database.User) error {
+	usage := fmt.Errorf("error: usage: feedauth <url> [basic <username> | bearer | cookie | none]")
+	if len(cmd.args) < 1 {
+		return usage
+	}
+
+	feed, err := s.db.GetFeed(context.Background(), cmd.args[0])
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("error: no feed with url '%s'", cmd.args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("error getting feed: %w", err)
+	}
+
+	// Step 1: Without a type, say whether the feed has credentials
+	if len(cmd.args) == 1 {
+		if feed.Auth == nil {
+			fmt.Printf("%s has no credentials\n", htmltext.Line(feed.Name))
+		} else {
+			fmt.Printf("%s has stored credentials\n", htmltext.Line(feed.Name))
+		}
+		return nil
+	}
+
+	// Step 2: Only the feed's creator or an admin can change its credentials
+	if feed.UserID != user.ID && !user.IsAdmin {
+		return fmt.Errorf("error: only the user who added '%s' or an admin can change its credentials", feed.Name)
+	}
+
+	// Step 3: Read the secret from the terminal or stdin, never from the
+	// command line where it would end up in shell history
+	auth := feedAuth{Type: cmd.args[1]}
+	switch {
+	case auth.Type == "none" && len(cmd.args) == 2:
+		err := s.db.SetFeedAuth(context.Background(), database.SetFeedAuthParams{ID: feed.ID})
+		if err != nil {
+			return fmt.Errorf("error removing credentials: %w", err)
+		}
+		fmt.Printf("Removed the credentials of %s\n", htmltext.Line(feed.Name))
+		return nil
+	case auth.Type == "basic" && len(cmd.args) == 3:
+		auth.Username = cmd.args[2]
+		auth.Password, err = readSecret("Password")
+	case auth.Type == "bearer" && len(cmd.args) == 2:
+		auth.Token, err = readSecret("Token")
+	case auth.Type == "cookie" && len(cmd.args) == 2:
+		auth.Cookie, err = readSecret("Cookie")
+	default:
+		return usage
+	}
+	if err != nil {
+		return err
+	}
+
+	// Step 4: Encrypt and store them
+	sealed, err := sealFeedAuth(s, feed, auth)
+	if err != nil {
+		return err
+	}
+	err = s.db.SetFeedAuth(context.Background(), database.SetFeedAuthParams{ID: feed.ID, Auth: sealed})
+	if err != nil {
+		return fmt.Errorf("error saving credentials: %w", err)
+	}
+
+	fmt.Printf("Saved %s credentials for %s\n", auth.Type, htmltext.Line(feed.Name))
+	return nil
+}
+
+// ===== Helper Functions =====
+
+// sealFeedAuth encrypts auth for storing with feed.
+func sealFeedAuth(s *state, feed database.Feed, auth feedAuth) ([]byte, error) {
+	key, err := secretKey(s)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(auth)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding credentials: %w", err)
+	}
+	return secret.Seal(key, plaintext, feed.ID[:])
+}
+
+// openFeedAuth decrypts the credentials of feed, returning nil if it has
+// none.
+func openFeedAuth(s *state, feed database.Feed) (*feedAuth, error) {
+	if feed.Auth == nil {
+		return nil, nil
+	}
+
+	key, err := secretKey(s)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := secret.Open(key, feed.Auth, feed.ID[:])
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting credentials: %w", err)
+	}
+
+	var auth feedAuth
+	if err := json.Unmarshal(plaintext, &auth); err != nil {
+		return nil, fmt.Errorf("error decoding credentials: %w", err)
+	}
+	return &auth, nil
+}
+
+func secretKey(s *state) ([]byte, error) {
+	encoded := s.cfg.SecretKey()
+	if encoded == "" {
+		return nil, fmt.Errorf("error: no secret key to encrypt feed credentials with; set secret_key in .gatorconfig.json or $%s (create one with: openssl rand -base64 32)", config.SecretKeyEnv)
+	}
+	key, err := secret.ParseKey(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("error reading secret key: %w", err)
+	}
+	return key, nil
+}
+
+// readSecret reads a line without echoing it when stdin is a terminal, or
+// from piped input otherwise.
+func readSecret(prompt string) (string, error) {
+	var value string
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Printf("%s: ", prompt)
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			return "", fmt.Errorf("error reading %s: %w", strings.ToLower(prompt), err)
+		}
+		value = string(b)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("error reading %s from stdin: %w", strings.ToLower(prompt), err)
+		}
+		value = strings.TrimRight(line, "\r\n")
+	}
+
+	if value == "" {
+		return "", fmt.Errorf("error: %s can't be empty", strings.ToLower(prompt))
+	}
+	return value, nil
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/adamararcane/gator/internal/store"
+	"github.com/google/uuid"
+)
+
+func handlerBackup(s *state, cmd command) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: usage: backup <file>")
+	}
+
+	// Read everything from one snapshot so the archive is consistent.
+	var a *archive
+	err := s.db.InReadTx(context.Background(), func(q store.Store) error {
+		var err error
+		a, err = exportArchive(q)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeArchive(a, cmd.args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Backed up %d users, %d feeds and %d posts to %s\n", len(a.Users), len(a.Feeds), len(a.Posts), cmd.args[0])
+	return nil
+}
+
+func handlerRestore(s *state, cmd command) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: usage: restore <file>")
+	}
+
+	a, err := readArchive(cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	var result restoreResult
+	err = withTx(s, func(q store.Store) error {
+		var err error
+		result, err = importArchive(q, a)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored %d users, %d feeds and %d posts from %s (%d already present)\n",
+		result.users, result.feeds, result.posts, cmd.args[0], result.existing)
+	return nil
+}
+
+// archiveVersion is bumped whenever the layout of the JSON archive changes
+// in a way older versions of gator couldn't read.
+const archiveVersion = 1
+
+// archive is a JSON snapshot of gator's data. Nullable columns are pointers
+// so they come out as null rather than sql.Null* structs.
+type archive struct {
+	Version    int                `json:"version"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Users      []archiveUser      `json:"users"`
+	Feeds      []archiveFeed      `json:"feeds"`
+	Follows    []archiveFollow    `json:"feed_follows"`
+	Posts      []archivePost      `json:"posts"`
+	PostStates []archivePostState `json:"post_states"`
+}
+
+type archiveUser struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	IsAdmin   bool      `json:"is_admin"`
+}
+
+type archiveFeed struct {
+	ID            uuid.UUID  `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Name          string     `json:"name"`
+	Url           string     `json:"url"`
+	UserID        uuid.UUID  `json:"user_id"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+
+	FetchIntervalSeconds *int32     `json:"fetch_interval_seconds,omitempty"`
+	NextFetchAt          *time.Time `json:"next_fetch_at,omitempty"`
+	DeadAt               *time.Time `json:"dead_at,omitempty"`
+	ParseWarning         *string    `json:"parse_warning,omitempty"`
+	// Auth is the feed's credentials, still encrypted with the secret key.
+	Auth []byte `json:"auth,omitempty"`
+}
+
+type archiveFollow struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	FeedID    uuid.UUID `json:"feed_id"`
+}
+
+type archivePost struct {
+	ID              uuid.UUID          `json:"id"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	FeedID          uuid.UUID          `json:"feed_id"`
+	Guid            string             `json:"guid"`
+	Title           string             `json:"title"`
+	Url             string             `json:"url"`
+	Description     *string            `json:"description"`
+	PublishedAt     *time.Time         `json:"published_at"`
+	Author          *string            `json:"author"`
+	Content         *string            `json:"content"`
+	ContentEncoded  *string            `json:"content_encoded"`
+	DurationSeconds *int32             `json:"duration_seconds"`
+	Episode         *int32             `json:"episode"`
+	Season          *int32             `json:"season"`
+	ImageUrl        *string            `json:"image_url"`
+	Categories      []string           `json:"categories,omitempty"`
+	Enclosures      []archiveEnclosure `json:"enclosures,omitempty"`
+}
+
+type archiveEnclosure struct {
+	Url    string `json:"url"`
+	Type   string `json:"type"`
+	Length *int64 `json:"length"`
+}
+
+type archivePostState struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	PostID    uuid.UUID  `json:"post_id"`
+	ReadAt    *time.Time `json:"read_at"`
+	StarredAt *time.Time `json:"starred_at,omitempty"`
+}
+
+// exportArchive reads everything in the database into an archive.
+func exportArchive(q store.Store) (*archive, error) {
+	ctx := context.Background()
+	a := &archive{Version: archiveVersion, CreatedAt: time.Now().UTC()}
+
+	users, err := q.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading users: %w", err)
+	}
+	for _, u := range users {
+		a.Users = append(a.Users, archiveUser{
+			ID:        u.ID,
+			CreatedAt: u.CreatedAt,
+			UpdatedAt: u.UpdatedAt,
+			Name:      u.Name,
+			IsAdmin:   u.IsAdmin,
+		})
+	}
+
+	feeds, err := q.ListFeeds(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading feeds: %w", err)
+	}
+	for _, f := range feeds {
+		a.Feeds = append(a.Feeds, archiveFeed{
+			ID:            f.ID,
+			CreatedAt:     f.CreatedAt,
+			UpdatedAt:     f.UpdatedAt,
+			Name:          f.Name,
+			Url:           f.Url,
+			UserID:        f.UserID,
+			LastFetchedAt: timePtr(f.LastFetchedAt),
+
+			FetchIntervalSeconds: int32Ptr(f.FetchIntervalSeconds),
+			NextFetchAt:          timePtr(f.NextFetchAt),
+			DeadAt:               timePtr(f.DeadAt),
+			ParseWarning:         stringPtr(f.ParseWarning),
+			Auth:                 f.Auth,
+		})
+	}
+
+	follows, err := q.ListFeedFollows(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading feed follows: %w", err)
+	}
+	for _, ff := range follows {
+		a.Follows = append(a.Follows, archiveFollow(ff))
+	}
+
+	categories, err := q.ListPostCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading post categories: %w", err)
+	}
+	categoriesByPost := map[uuid.UUID][]string{}
+	for _, c := range categories {
+		categoriesByPost[c.PostID] = append(categoriesByPost[c.PostID], c.Name)
+	}
+
+	enclosures, err := q.ListPostEnclosures(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading post enclosures: %w", err)
+	}
+	enclosuresByPost := map[uuid.UUID][]archiveEnclosure{}
+	for _, e := range enclosures {
+		enclosuresByPost[e.PostID] = append(enclosuresByPost[e.PostID], archiveEnclosure{
+			Url:    e.Url,
+			Type:   e.Type,
+			Length: int64Ptr(e.Length),
+		})
+	}
+
+	posts, err := q.ListPosts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading posts: %w", err)
+	}
+	for _, p := range posts {
+		a.Posts = append(a.Posts, archivePost{
+			ID:              p.ID,
+			CreatedAt:       p.CreatedAt,
+			UpdatedAt:       p.UpdatedAt,
+			FeedID:          p.FeedID,
+			Guid:            p.Guid,
+			Title:           p.Title,
+			Url:             p.Url,
+			Description:     stringPtr(p.Description),
+			PublishedAt:     timePtr(p.PublishedAt),
+			Author:          stringPtr(p.Author),
+			Content:         stringPtr(p.Content),
+			ContentEncoded:  stringPtr(p.ContentEncoded),
+			DurationSeconds: int32Ptr(p.DurationSeconds),
+			Episode:         int32Ptr(p.Episode),
+			Season:          int32Ptr(p.Season),
+			ImageUrl:        stringPtr(p.ImageUrl),
+			Categories:      categoriesByPost[p.ID],
+			Enclosures:      enclosuresByPost[p.ID],
+		})
+	}
+
+	states, err := q.ListPostStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading post states: %w", err)
+	}
+	for _, ps := range states {
+		a.PostStates = append(a.PostStates, archivePostState{
+			UserID:    ps.UserID,
+			PostID:    ps.PostID,
+			ReadAt:    timePtr(ps.ReadAt),
+			StarredAt: timePtr(ps.StarredAt),
+		})
+	}
+
+	return a, nil
+}
+
+// createArchive creates the file an archive will be written to, refusing
+// to overwrite an existing file.
+func createArchive(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// writeArchive saves a to path, refusing to overwrite an existing file.
+func writeArchive(a *archive, path string) error {
+	file, err := createArchive(path)
+	if err != nil {
+		return err
+	}
+	return encodeArchive(a, file)
+}
+
+// encodeArchive writes a to file as JSON and closes it.
+func encodeArchive(a *archive, file *os.File) error {
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(a); err != nil {
+		return fmt.Errorf("error writing %s: %w", file.Name(), err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing %s: %w", file.Name(), err)
+	}
+	return nil
+}
+
+// keepPosts trims the archive down to posts and their read state.
+func (a *archive) keepPosts() {
+	a.Users, a.Feeds, a.Follows = nil, nil, nil
+}
+
+// keepFeeds trims the archive down to feeds and everything hanging off
+// them.
+func (a *archive) keepFeeds() {
+	a.Users = nil
+}
+
+// keepUser trims the archive down to one user, the feeds they added and
+// everything that goes with them when the user is deleted.
+func (a *archive) keepUser(userID uuid.UUID) {
+	a.Users = filterSlice(a.Users, func(u archiveUser) bool { return u.ID == userID })
+
+	feeds := map[uuid.UUID]bool{}
+	a.Feeds = filterSlice(a.Feeds, func(f archiveFeed) bool {
+		feeds[f.ID] = f.UserID == userID
+		return feeds[f.ID]
+	})
+	a.Follows = filterSlice(a.Follows, func(ff archiveFollow) bool { return ff.UserID == userID || feeds[ff.FeedID] })
+
+	posts := map[uuid.UUID]bool{}
+	a.Posts = filterSlice(a.Posts, func(p archivePost) bool {
+		posts[p.ID] = feeds[p.FeedID]
+		return posts[p.ID]
+	})
+	a.PostStates = filterSlice(a.PostStates, func(ps archivePostState) bool { return ps.UserID == userID || posts[ps.PostID] })
+}
+
+func readArchive(path string) (*archive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var a archive
+	if err := json.NewDecoder(file).Decode(&a); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	if a.Version < 1 {
+		return nil, fmt.Errorf("error: %s is not a gator backup", path)
+	}
+	if a.Version > archiveVersion {
+		return nil, fmt.Errorf("error: %s was written by a newer version of gator (archive version %d, this version reads up to %d)", path, a.Version, archiveVersion)
+	}
+	return &a, nil
+}
+
+type restoreResult struct {
+	users, feeds, posts int
+	existing            int
+}
+
+// importArchive adds the contents of a to the database. Users that already
+// exist are matched by name, feeds by url and posts by feed and guid, so
+// restoring the same archive twice, or into a database that already holds
+// some of it, adds only what is missing.
+func importArchive(q store.Store, a *archive) (restoreResult, error) {
+	ctx := context.Background()
+	var result restoreResult
+
+	// IDs in the archive mapped to the rows they matched in this database.
+	// Anything not in the archive (a reset snapshot of just the posts, say)
+	// is assumed to still exist under the same ID.
+	ids := map[uuid.UUID]uuid.UUID{}
+	mapID := func(id uuid.UUID) uuid.UUID {
+		if mapped, ok := ids[id]; ok {
+			return mapped
+		}
+		return id
+	}
+
+	for _, u := range a.Users {
+		existing, err := q.GetUser(ctx, u.Name)
+		if err == nil {
+			ids[u.ID] = existing.ID
+			result.existing++
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return result, fmt.Errorf("error looking up user %s: %w", u.Name, err)
+		}
+
+		restored, err := q.RestoreUser(ctx, database.RestoreUserParams{
+			ID:        u.ID,
+			CreatedAt: u.CreatedAt,
+			UpdatedAt: u.UpdatedAt,
+			Name:      u.Name,
+			IsAdmin:   u.IsAdmin,
+		})
+		if err != nil {
+			return result, fmt.Errorf("error restoring user %s: %w", u.Name, err)
+		}
+		if restored == 0 {
+			result.existing++
+			continue
+		}
+		result.users++
+	}
+
+	for _, f := range a.Feeds {
+		existing, err := q.GetFeed(ctx, f.Url)
+		if err == nil {
+			ids[f.ID] = existing.ID
+			result.existing++
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return result, fmt.Errorf("error looking up feed %s: %w", f.Url, err)
+		}
+
+		restored, err := q.RestoreFeed(ctx, database.RestoreFeedParams{
+			ID:            f.ID,
+			CreatedAt:     f.CreatedAt,
+			UpdatedAt:     f.UpdatedAt,
+			Name:          f.Name,
+			Url:           f.Url,
+			UserID:        mapID(f.UserID),
+			LastFetchedAt: nullTime(f.LastFetchedAt),
+
+			FetchIntervalSeconds: nullInt32(f.FetchIntervalSeconds),
+			NextFetchAt:          nullTime(f.NextFetchAt),
+			DeadAt:               nullTime(f.DeadAt),
+			ParseWarning:         nullStringPtr(f.ParseWarning),
+			Auth:                 f.Auth,
+		})
+		if err != nil {
+			return result, fmt.Errorf("error restoring feed %s: %w", f.Url, err)
+		}
+		if restored == 0 {
+			result.existing++
+			continue
+		}
+		result.feeds++
+	}
+
+	for _, ff := range a.Follows {
+		err := q.RestoreFeedFollow(ctx, database.RestoreFeedFollowParams{
+			ID:        ff.ID,
+			CreatedAt: ff.CreatedAt,
+			UpdatedAt: ff.UpdatedAt,
+			UserID:    mapID(ff.UserID),
+			FeedID:    mapID(ff.FeedID),
+		})
+		if err != nil {
+			return result, fmt.Errorf("error restoring feed follow: %w", err)
+		}
+	}
+
+	for _, p := range a.Posts {
+		feedID := mapID(p.FeedID)
+		existing, err := q.GetPostByGuid(ctx, database.GetPostByGuidParams{FeedID: feedID, Guid: p.Guid})
+		if err == nil {
+			ids[p.ID] = existing.ID
+			result.existing++
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return result, fmt.Errorf("error looking up post %s: %w", p.Guid, err)
+		}
+
+		restored, err := q.RestorePost(ctx, database.RestorePostParams{
+			ID:              p.ID,
+			CreatedAt:       p.CreatedAt,
+			UpdatedAt:       p.UpdatedAt,
+			Title:           p.Title,
+			Url:             p.Url,
+			Description:     nullStringPtr(p.Description),
+			PublishedAt:     nullTime(p.PublishedAt),
+			FeedID:          feedID,
+			Content:         nullStringPtr(p.Content),
+			Guid:            p.Guid,
+			Author:          nullStringPtr(p.Author),
+			ContentEncoded:  nullStringPtr(p.ContentEncoded),
+			DurationSeconds: nullInt32(p.DurationSeconds),
+			Episode:         nullInt32(p.Episode),
+			Season:          nullInt32(p.Season),
+			ImageUrl:        nullStringPtr(p.ImageUrl),
+		})
+		if err != nil {
+			return result, fmt.Errorf("error restoring post %s: %w", p.Guid, err)
+		}
+		// Another row already holds the archived id, so the post wasn't
+		// restored and has no categories or enclosures of its own to add.
+		if restored == 0 {
+			result.existing++
+			continue
+		}
+
+		for _, category := range p.Categories {
+			if err := q.AddPostCategory(ctx, database.AddPostCategoryParams{PostID: p.ID, Name: category}); err != nil {
+				return result, fmt.Errorf("error restoring post category: %w", err)
+			}
+		}
+		for _, e := range p.Enclosures {
+			err := q.AddPostEnclosure(ctx, database.AddPostEnclosureParams{
+				PostID: p.ID,
+				Url:    e.Url,
+				Type:   e.Type,
+				Length: nullInt64(e.Length),
+			})
+			if err != nil {
+				return result, fmt.Errorf("error restoring post enclosure: %w", err)
+			}
+		}
+		result.posts++
+	}
+
+	for _, ps := range a.PostStates {
+		err := q.RestorePostState(ctx, database.RestorePostStateParams{
+			UserID:    mapID(ps.UserID),
+			PostID:    mapID(ps.PostID),
+			ReadAt:    nullTime(ps.ReadAt),
+			StarredAt: nullTime(ps.StarredAt),
+		})
+		if err != nil {
+			return result, fmt.Errorf("error restoring read state: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// ===== Helper Functions =====
+
+func filterSlice[T any](items []T, keep func(T) bool) []T {
+	var out []T
+	for _, item := range items {
+		if keep(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func stringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+func int32Ptr(n sql.NullInt32) *int32 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int32
+}
+
+func int64Ptr(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+func nullStringPtr(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func nullInt32(n *int32) sql.NullInt32 {
+	if n == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: *n, Valid: true}
+}
+
+func nullInt64(n *int64) sql.NullInt64 {
+	if n == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *n, Valid: true}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/adamararcane/gator/internal/htmltext"
+)
+
+// openBrowser launches browserCmd for the given url without waiting for it
+// to exit. browserCmd may contain arguments and a %s placeholder for the url;
+// when it is empty the platform's default opener is used. Only http and
+// https urls are opened, since the url comes from the feed and anything else
+// could be taken as an option or a local file by the opener.
+func openBrowser(browserCmd, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("error: won't open '%s', it isn't an http or https url", htmltext.Line(rawURL))
+	}
+	target := u.String()
+
+	var cmd *exec.Cmd
+	if fields := strings.Fields(browserCmd); len(fields) > 0 {
+		args := fields[1:]
+		if strings.Contains(browserCmd, "%s") {
+			for i, arg := range args {
+				args[i] = strings.ReplaceAll(arg, "%s", target)
+			}
+		} else {
+			args = append(args, target)
+		}
+		cmd = exec.Command(fields[0], args...)
+	} else {
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("open", target)
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+		default:
+			cmd = exec.Command("xdg-open", target)
+		}
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error opening browser: %w", err)
+	}
+	return cmd.Process.Release()
+}
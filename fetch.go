@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adamararcane/gator/internal/config"
+)
+
+const (
+	defaultFetchTimeout = 30 * time.Second
+	defaultMaxBodySize  = 10 << 20
+	defaultMaxRedirects = 10
+	defaultContact      = "https://github.com/adamararcane/gator"
+)
+
+// Each way a fetch can fail wraps one of these, so callers can tell them
+// apart with errors.Is. Non-2xx responses are an *httpStatusError.
+var (
+	errConnection       = errors.New("couldn't connect")
+	errTimeout          = errors.New("timed out")
+	errTooManyRedirects = errors.New("too many redirects")
+	errBodyTooLarge     = errors.New("response body too large")
+	errInvalidFeed      = errors.New("not a valid feed")
+)
+
+// fetcher is the HTTP client feeds are fetched with.
+type fetcher struct {
+	client    *http.Client
+	userAgent string
+	maxBody   int64
+}
+
+// newFetcher builds a fetcher from the http section of the config.
+func newFetcher(cfg config.HTTP) (*fetcher, error) {
+	timeout := defaultFetchTimeout
+	if cfg.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing http timeout: %w", err)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing http proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	maxRedirects := cfg.Max_redirects
+	if maxRedirects == 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	checkRedirect := func(req *http.Request, via []*http.Request) error {
+		if maxRedirects < 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w (stopped after %d)", errTooManyRedirects, maxRedirects)
+		}
+		return nil
+	}
+
+	userAgent := cfg.User_agent
+	if userAgent == "" {
+		contact := cfg.Contact
+		if contact == "" {
+			contact = defaultContact
+		}
+		userAgent = fmt.Sprintf("Gator/%s (+%s)", version, contact)
+	}
+
+	maxBody := cfg.Max_body_size
+	if maxBody <= 0 {
+		maxBody = defaultMaxBodySize
+	}
+
+	return &fetcher{
+		client: &http.Client{
+			Timeout:       timeout,
+			Transport:     transport,
+			CheckRedirect: checkRedirect,
+		},
+		userAgent: userAgent,
+		maxBody:   maxBody,
+	}, nil
+}
+
+// downloadClient returns a copy of the client without its overall timeout,
+// for media downloads that can take far longer than a feed. The transport,
+// and so the proxy and connection timeouts, are shared.
+func (f *fetcher) downloadClient() *http.Client {
+	client := *f.client
+	client.Timeout = 0
+	return &client
+}
+
+// readBody reads the body of resp, refusing to read more than the
+// configured limit.
+func (f *fetcher) readBody(resp *http.Response) ([]byte, error) {
+	if resp.ContentLength > f.maxBody {
+		return nil, fmt.Errorf("%w (%d bytes, limit %d)", errBodyTooLarge, resp.ContentLength, f.maxBody)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBody+1))
+	feedFetchBytes.Add(float64(len(body)))
+	if err != nil {
+		return nil, fetchError(err)
+	}
+	if int64(len(body)) > f.maxBody {
+		return nil, fmt.Errorf("%w (limit %d bytes)", errBodyTooLarge, f.maxBody)
+	}
+	return body, nil
+}
+
+// permanentRedirect returns where the request behind resp was permanently
+// redirected to, following the chain of 301 and 308 responses from the
+// original request up to the first temporary redirect. It returns "" if the
+// first redirect wasn't permanent.
+func permanentRedirect(resp *http.Response) string {
+	req := resp.Request
+	chain := []*http.Request{req}
+	for req.Response != nil {
+		req = req.Response.Request
+		chain = append(chain, req)
+	}
+	slices.Reverse(chain)
+
+	moved := ""
+	for _, req := range chain[1:] {
+		if code := req.Response.StatusCode; code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+			break
+		}
+		moved = req.URL.String()
+	}
+	return moved
+}
+
+// fetchError sorts an error from the HTTP client into one of the fetch
+// errors above, keeping the original for its detail.
+func fetchError(err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errTooManyRedirects):
+		return err
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("%w: %w", errTimeout, err)
+	default:
+		return fmt.Errorf("%w: %w", errConnection, err)
+	}
+}
+
+// httpStatusError is returned for responses other than 2xx. retryAfter
+// holds how long the server asked us to wait before trying again.
+type httpStatusError struct {
+	code       int
+	status     string
+	retryAfter time.Duration
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("server responded %s", e.status)
+}
+
+// parseRetryAfter reads a Retry-After header, which is either a number of
+// seconds or an HTTP date, capped at maxFetchDelay.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(min(max(seconds, 0), int(maxFetchDelay/time.Second))) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil && t.After(now) {
+		return min(t.Sub(now), maxFetchDelay)
+	}
+	return 0
+}
+
+// parseMaxAge returns the max-age directive of a Cache-Control header,
+// capped at maxFetchDelay.
+func parseMaxAge(header string) time.Duration {
+	for _, directive := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds > 0 {
+			return time.Duration(min(seconds, int(maxFetchDelay/time.Second))) * time.Second
+		}
+	}
+	return 0
+}
@@ -3,18 +3,22 @@ package config
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 )
 
+// Read reads ~/.gatorconfig.json.
 func Read() (Config, error) {
 	ConfigFilePath, err := getConfigFilePath()
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to get config file path: %w", err)
 	}
 
-	fullFilePath := ConfigFilePath + configFileName
+	return ReadFile(ConfigFilePath + configFileName)
+}
 
+// ReadFile reads the config at fullFilePath. SetUser writes back to the same
+// file.
+func ReadFile(fullFilePath string) (Config, error) {
 	fileContent, err := os.ReadFile(fullFilePath)
 	if err != nil {
 		return Config{}, fmt.Errorf("error reading file: %w", err)
@@ -23,36 +27,110 @@ func Read() (Config, error) {
 	var config Config
 	err = json.Unmarshal(fileContent, &config)
 	if err != nil {
-		log.Printf("Error decoding config: %s", err)
 		return Config{}, fmt.Errorf("error decoding JSON: %w", err)
 	}
 
+	config.path = fullFilePath
 	return config, nil
 }
 
 type Config struct {
 	Db_url            string `json:"db_url"`
 	Current_user_name string `json:"current_user_name"`
+	Browser           string `json:"browser,omitempty"`
+	Extract_content   bool   `json:"extract_content,omitempty"`
+	Download_dir      string `json:"download_dir,omitempty"`
+
+	// Retention limits how many posts are kept. Feed_retention overrides it
+	// for individual feeds, keyed by feed url.
+	Retention      Retention            `json:"retention,omitzero"`
+	Feed_retention map[string]Retention `json:"feed_retention,omitempty"`
+
+	// HTTP configures the client feeds are fetched with.
+	HTTP HTTP `json:"http,omitzero"`
+
+	// Secret_key encrypts feed credentials; see SecretKey.
+	Secret_key string `json:"secret_key,omitempty"`
+
+	Log Log `json:"log,omitzero"`
+
+	// path is the file the config was read from.
+	path string
 }
 
-func (cfg *Config) SetUser(name string) error {
-	ConfigFilePath, err := getConfigFilePath()
-	if err != nil {
-		return fmt.Errorf("failed to get config file path: %w", err)
+// Log configures the log messages written to stderr: Level is one of
+// debug, info (the default), warn or error, and Format is text (the
+// default) or json.
+type Log struct {
+	Level  string `json:"level,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// SecretKeyEnv overrides secret_key, so the key needn't be stored next to
+// the database url.
+const SecretKeyEnv = "GATOR_SECRET_KEY"
+
+// SecretKey returns the base64 key feed credentials are encrypted with,
+// from $GATOR_SECRET_KEY if it is set, otherwise from the config.
+func (cfg Config) SecretKey() string {
+	if key := os.Getenv(SecretKeyEnv); key != "" {
+		return key
 	}
+	return cfg.Secret_key
+}
 
-	fullFilePath := ConfigFilePath + configFileName
+// HTTP holds the feed fetcher's settings. Zero values use the defaults:
+// a 30s timeout, a 10 MiB body limit, the proxy from the environment
+// (HTTPS_PROXY and friends), and up to 10 redirects; -1 Max_redirects
+// doesn't follow redirects at all. User_agent replaces the default
+// "Gator/<version> (+<contact>)" entirely.
+type HTTP struct {
+	Timeout       string `json:"timeout,omitempty"`
+	Max_body_size int64  `json:"max_body_size,omitempty"`
+	Proxy         string `json:"proxy,omitempty"`
+	User_agent    string `json:"user_agent,omitempty"`
+	Contact       string `json:"contact,omitempty"`
+	Max_redirects int    `json:"max_redirects,omitempty"`
+}
 
-	fileContent, err := os.ReadFile(fullFilePath)
-	if err != nil {
-		return fmt.Errorf("error reading file: %w", err)
+// Retention prunes posts older than Days or beyond the Count newest of a
+// feed. Zero means no limit; in Feed_retention it means the global limit
+// applies, and -1 lifts it for that feed.
+type Retention struct {
+	Days  int `json:"days,omitempty"`
+	Count int `json:"count,omitempty"`
+}
+
+// RetentionFor returns the retention policy for the feed at url, taking
+// each limit from the feed's own settings if it has them.
+func (cfg Config) RetentionFor(url string) Retention {
+	retention := cfg.Retention
+	if feed, ok := cfg.Feed_retention[url]; ok {
+		if feed.Days != 0 {
+			retention.Days = max(feed.Days, 0)
+		}
+		if feed.Count != 0 {
+			retention.Count = max(feed.Count, 0)
+		}
 	}
+	return retention
+}
 
-	var config Config
-	err = json.Unmarshal(fileContent, &config)
+// SetUser saves name as the logged-in user in the file the config was read
+// from, or ~/.gatorconfig.json for a config that wasn't read from a file.
+func (cfg *Config) SetUser(name string) error {
+	fullFilePath := cfg.path
+	if fullFilePath == "" {
+		ConfigFilePath, err := getConfigFilePath()
+		if err != nil {
+			return fmt.Errorf("failed to get config file path: %w", err)
+		}
+		fullFilePath = ConfigFilePath + configFileName
+	}
+
+	config, err := ReadFile(fullFilePath)
 	if err != nil {
-		log.Printf("Error decoding config: %s", err)
-		return fmt.Errorf("error decoding JSON: %w", err)
+		return err
 	}
 
 	config.Current_user_name = name
@@ -75,13 +75,15 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT f.name, f.url
+SELECT f.id, f.name, f.url
 FROM feed_follows ff
 JOIN feeds f ON ff.feed_id = f.id
 WHERE ff.user_id = $1
+ORDER BY f.name
 `
 
 type GetFeedFollowsForUserRow struct {
+	ID   uuid.UUID
 	Name string
 	Url  string
 }
@@ -95,7 +97,7 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	var items []GetFeedFollowsForUserRow
 	for rows.Next() {
 		var i GetFeedFollowsForUserRow
-		if err := rows.Scan(&i.Name, &i.Url); err != nil {
+		if err := rows.Scan(&i.ID, &i.Name, &i.Url); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -109,6 +111,64 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	return items, nil
 }
 
+const listFeedFollows = `-- name: ListFeedFollows :many
+SELECT id, created_at, updated_at, user_id, feed_id FROM feed_follows ORDER BY created_at
+`
+
+func (q *Queries) ListFeedFollows(ctx context.Context) ([]FeedFollow, error) {
+	rows, err := q.db.QueryContext(ctx, listFeedFollows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeedFollow
+	for rows.Next() {
+		var i FeedFollow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreFeedFollow = `-- name: RestoreFeedFollow :exec
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING
+`
+
+type RestoreFeedFollowParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+}
+
+func (q *Queries) RestoreFeedFollow(ctx context.Context, arg RestoreFeedFollowParams) error {
+	_, err := q.db.ExecContext(ctx, restoreFeedFollow,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+	)
+	return err
+}
+
 const unfollowFeed = `-- name: UnfollowFeed :exec
 DELETE FROM feed_follows WHERE user_id = $1 AND feed_id = $2
 `
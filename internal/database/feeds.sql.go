@@ -7,11 +7,23 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const countFeedsForUser = `-- name: CountFeedsForUser :one
+SELECT COUNT(*) FROM feeds WHERE user_id = $1
+`
+
+func (q *Queries) CountFeedsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFeedsForUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
 VALUES (
@@ -22,7 +34,7 @@ VALUES (
     $5,
     $6
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_interval_seconds, next_fetch_at, dead_at, parse_warning, auth
 `
 
 type CreateFeedParams struct {
@@ -52,12 +64,51 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.DeadAt,
+		&i.ParseWarning,
+		&i.Auth,
 	)
 	return i, err
 }
 
+const deferFeedFetch = `-- name: DeferFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = NOW() + $1::int * INTERVAL '1 second'
+WHERE id = $2
+`
+
+type DeferFeedFetchParams struct {
+	DelaySeconds int32
+	ID           uuid.UUID
+}
+
+func (q *Queries) DeferFeedFetch(ctx context.Context, arg DeferFeedFetchParams) error {
+	_, err := q.db.ExecContext(ctx, deferFeedFetch, arg.DelaySeconds, arg.ID)
+	return err
+}
+
+const deleteAllFeeds = `-- name: DeleteAllFeeds :exec
+DELETE FROM feeds
+`
+
+func (q *Queries) DeleteAllFeeds(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllFeeds)
+	return err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
 const getFeed = `-- name: GetFeed :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds WHERE url = $1 LIMIT 1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_interval_seconds, next_fetch_at, dead_at, parse_warning, auth FROM feeds WHERE url = $1 LIMIT 1
 `
 
 func (q *Queries) GetFeed(ctx context.Context, url string) (Feed, error) {
@@ -71,18 +122,49 @@ func (q *Queries) GetFeed(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.DeadAt,
+		&i.ParseWarning,
+		&i.Auth,
+	)
+	return i, err
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_interval_seconds, next_fetch_at, dead_at, parse_warning, auth FROM feeds WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.DeadAt,
+		&i.ParseWarning,
+		&i.Auth,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT name, url, user_id FROM feeds
+SELECT name, url, user_id, dead_at, parse_warning FROM feeds
 `
 
 type GetFeedsRow struct {
-	Name   string
-	Url    string
-	UserID uuid.UUID
+	Name         string
+	Url          string
+	UserID       uuid.UUID
+	DeadAt       sql.NullTime
+	ParseWarning sql.NullString
 }
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
@@ -94,7 +176,13 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	var items []GetFeedsRow
 	for rows.Next() {
 		var i GetFeedsRow
-		if err := rows.Scan(&i.Name, &i.Url, &i.UserID); err != nil {
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.DeadAt,
+			&i.ParseWarning,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -109,33 +197,311 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 }
 
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.fetch_interval_seconds, feeds.next_fetch_at, feeds.dead_at, feeds.parse_warning, feeds.auth,
+    EXTRACT(EPOCH FROM NOW() - COALESCE(next_fetch_at, updated_at))::float8 AS due_seconds
+FROM feeds
+WHERE dead_at IS NULL AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
 LIMIT 1
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
+type GetNextFeedToFetchRow struct {
+	Feed       Feed
+	DueSeconds float64
+}
+
+// due_seconds is how long the feed has been due. It is worked out here
+// because next_fetch_at holds the server's local time, written with NOW().
+func (q *Queries) GetNextFeedToFetch(ctx context.Context) (GetNextFeedToFetchRow, error) {
 	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i Feed
+	var i GetNextFeedToFetchRow
 	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Name,
-		&i.Url,
-		&i.UserID,
-		&i.LastFetchedAt,
+		&i.Feed.ID,
+		&i.Feed.CreatedAt,
+		&i.Feed.UpdatedAt,
+		&i.Feed.Name,
+		&i.Feed.Url,
+		&i.Feed.UserID,
+		&i.Feed.LastFetchedAt,
+		&i.Feed.FetchIntervalSeconds,
+		&i.Feed.NextFetchAt,
+		&i.Feed.DeadAt,
+		&i.Feed.ParseWarning,
+		&i.Feed.Auth,
+		&i.DueSeconds,
 	)
 	return i, err
 }
 
+const getRecentPostTimes = `-- name: GetRecentPostTimes :many
+SELECT published_at FROM posts
+WHERE feed_id = $1 AND published_at IS NOT NULL
+ORDER BY published_at DESC
+LIMIT $2
+`
+
+type GetRecentPostTimesParams struct {
+	FeedID uuid.UUID
+	Limit  int32
+}
+
+func (q *Queries) GetRecentPostTimes(ctx context.Context, arg GetRecentPostTimesParams) ([]sql.NullTime, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPostTimes, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullTime
+	for rows.Next() {
+		var published_at sql.NullTime
+		if err := rows.Scan(&published_at); err != nil {
+			return nil, err
+		}
+		items = append(items, published_at)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeeds = `-- name: ListFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_interval_seconds, next_fetch_at, dead_at, parse_warning, auth FROM feeds ORDER BY created_at
+`
+
+func (q *Queries) ListFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, listFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.FetchIntervalSeconds,
+			&i.NextFetchAt,
+			&i.DeadAt,
+			&i.ParseWarning,
+			&i.Auth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markFeedDead = `-- name: MarkFeedDead :exec
+UPDATE feeds SET dead_at = NOW(), updated_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) MarkFeedDead(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markFeedDead, id)
+	return err
+}
+
 const markFeedFetched = `-- name: MarkFeedFetched :exec
 UPDATE feeds
-SET updated_at = NOW(), last_fetched_at = NOW()
+SET updated_at = NOW(),
+    last_fetched_at = NOW(),
+    next_fetch_at = NOW() + $1::int * INTERVAL '1 second'
+WHERE id = $2
+`
+
+type MarkFeedFetchedParams struct {
+	IntervalSeconds int32
+	ID              uuid.UUID
+}
+
+func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.IntervalSeconds, arg.ID)
+	return err
+}
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = $1
+WHERE feed_follows.feed_id = $2
+  AND feed_follows.user_id NOT IN (
+      SELECT other.user_id FROM feed_follows AS other WHERE other.feed_id = $1
+  )
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const moveFeedPostStates = `-- name: MoveFeedPostStates :exec
+INSERT INTO post_states (user_id, post_id, read_at, starred_at)
+SELECT post_states.user_id, target.id, post_states.read_at, post_states.starred_at
+FROM post_states
+JOIN posts AS source ON source.id = post_states.post_id
+JOIN posts AS target ON target.guid = source.guid AND target.feed_id = $1
+WHERE source.feed_id = $2
+ON CONFLICT (user_id, post_id) DO UPDATE SET
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    starred_at = COALESCE(post_states.starred_at, EXCLUDED.starred_at)
+`
+
+type MoveFeedPostStatesParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+// Carries read and starred marks over from posts that stay behind because
+// the target feed already has a post with the same guid.
+func (q *Queries) MoveFeedPostStates(ctx context.Context, arg MoveFeedPostStatesParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedPostStates, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const moveFeedPosts = `-- name: MoveFeedPosts :exec
+UPDATE posts
+SET feed_id = $1
+WHERE posts.feed_id = $2
+  AND posts.guid NOT IN (
+      SELECT other.guid FROM posts AS other WHERE other.feed_id = $1
+  )
+`
+
+type MoveFeedPostsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedPosts(ctx context.Context, arg MoveFeedPostsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedPosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const restoreFeed = `-- name: RestoreFeed :execrows
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_interval_seconds, next_fetch_at, dead_at, parse_warning, auth)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT DO NOTHING
+`
+
+type RestoreFeedParams struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Name                 string
+	Url                  string
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	FetchIntervalSeconds sql.NullInt32
+	NextFetchAt          sql.NullTime
+	DeadAt               sql.NullTime
+	ParseWarning         sql.NullString
+	Auth                 []byte
+}
+
+func (q *Queries) RestoreFeed(ctx context.Context, arg RestoreFeedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreFeed,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Name,
+		arg.Url,
+		arg.UserID,
+		arg.LastFetchedAt,
+		arg.FetchIntervalSeconds,
+		arg.NextFetchAt,
+		arg.DeadAt,
+		arg.ParseWarning,
+		arg.Auth,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const reviveFeed = `-- name: ReviveFeed :exec
+UPDATE feeds SET dead_at = NULL, next_fetch_at = NULL, updated_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) ReviveFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, reviveFeed, id)
+	return err
+}
+
+const setFeedAuth = `-- name: SetFeedAuth :exec
+UPDATE feeds SET auth = $2, updated_at = NOW() WHERE id = $1
+`
+
+type SetFeedAuthParams struct {
+	ID   uuid.UUID
+	Auth []byte
+}
+
+func (q *Queries) SetFeedAuth(ctx context.Context, arg SetFeedAuthParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedAuth, arg.ID, arg.Auth)
+	return err
+}
+
+const setFeedFetchInterval = `-- name: SetFeedFetchInterval :exec
+UPDATE feeds
+SET fetch_interval_seconds = $2, next_fetch_at = NULL, dead_at = NULL, updated_at = NOW()
 WHERE id = $1
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+type SetFeedFetchIntervalParams struct {
+	ID                   uuid.UUID
+	FetchIntervalSeconds sql.NullInt32
+}
+
+func (q *Queries) SetFeedFetchInterval(ctx context.Context, arg SetFeedFetchIntervalParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchInterval, arg.ID, arg.FetchIntervalSeconds)
+	return err
+}
+
+const setFeedParseWarning = `-- name: SetFeedParseWarning :exec
+UPDATE feeds SET parse_warning = $2 WHERE id = $1
+`
+
+type SetFeedParseWarningParams struct {
+	ID           uuid.UUID
+	ParseWarning sql.NullString
+}
+
+func (q *Queries) SetFeedParseWarning(ctx context.Context, arg SetFeedParseWarningParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedParseWarning, arg.ID, arg.ParseWarning)
+	return err
+}
+
+const setFeedUrl = `-- name: SetFeedUrl :exec
+UPDATE feeds SET url = $2, updated_at = NOW() WHERE id = $1
+`
+
+type SetFeedUrlParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) SetFeedUrl(ctx context.Context, arg SetFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedUrl, arg.ID, arg.Url)
 	return err
 }
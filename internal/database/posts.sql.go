@@ -13,39 +13,150 @@ import (
 	"github.com/google/uuid"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id,  title, url, description, published_at, feed_id, created_at, updated_at)
-VALUES (
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    NOW(),
-    NOW()
-)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+const addPostCategory = `-- name: AddPostCategory :exec
+INSERT INTO post_categories (post_id, name)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
 `
 
-type CreatePostParams struct {
-	ID          uuid.UUID
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+type AddPostCategoryParams struct {
+	PostID uuid.UUID
+	Name   string
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
-		arg.Title,
+func (q *Queries) AddPostCategory(ctx context.Context, arg AddPostCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, addPostCategory, arg.PostID, arg.Name)
+	return err
+}
+
+const addPostEnclosure = `-- name: AddPostEnclosure :exec
+INSERT INTO post_enclosures (post_id, url, type, length)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING
+`
+
+type AddPostEnclosureParams struct {
+	PostID uuid.UUID
+	Url    string
+	Type   string
+	Length sql.NullInt64
+}
+
+func (q *Queries) AddPostEnclosure(ctx context.Context, arg AddPostEnclosureParams) error {
+	_, err := q.db.ExecContext(ctx, addPostEnclosure,
+		arg.PostID,
 		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
-		arg.FeedID,
+		arg.Type,
+		arg.Length,
 	)
+	return err
+}
+
+const deleteAllPosts = `-- name: DeleteAllPosts :exec
+DELETE FROM posts
+`
+
+func (q *Queries) DeleteAllPosts(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllPosts)
+	return err
+}
+
+const deletePostCategories = `-- name: DeletePostCategories :exec
+DELETE FROM post_categories WHERE post_id = $1
+`
+
+func (q *Queries) DeletePostCategories(ctx context.Context, postID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePostCategories, postID)
+	return err
+}
+
+const deletePostEnclosures = `-- name: DeletePostEnclosures :exec
+DELETE FROM post_enclosures WHERE post_id = $1
+`
+
+func (q *Queries) DeletePostEnclosures(ctx context.Context, postID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePostEnclosures, postID)
+	return err
+}
+
+const getEpisodesForUser = `-- name: GetEpisodesForUser :many
+SELECT posts.id, posts.title, posts.published_at, posts.duration_seconds, posts.episode, posts.season,
+    feeds.name AS feed_name, post_enclosures.url AS enclosure_url, post_enclosures.type AS enclosure_type,
+    post_enclosures.length AS enclosure_length
+FROM posts
+JOIN post_enclosures ON post_enclosures.post_id = posts.id
+    AND post_enclosures.url = (SELECT MIN(e.url) FROM post_enclosures e WHERE e.post_id = posts.id)
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+ORDER BY posts.published_at DESC
+LIMIT $2
+`
+
+type GetEpisodesForUserParams struct {
+	UserID uuid.UUID
+	Limit  int32
+}
+
+type GetEpisodesForUserRow struct {
+	ID              uuid.UUID
+	Title           string
+	PublishedAt     sql.NullTime
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	FeedName        string
+	EnclosureUrl    string
+	EnclosureType   string
+	EnclosureLength sql.NullInt64
+}
+
+// A post is listed once, with its first enclosure, however many it has.
+func (q *Queries) GetEpisodesForUser(ctx context.Context, arg GetEpisodesForUserParams) ([]GetEpisodesForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getEpisodesForUser, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEpisodesForUserRow
+	for rows.Next() {
+		var i GetEpisodesForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.PublishedAt,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.FeedName,
+			&i.EnclosureUrl,
+			&i.EnclosureType,
+			&i.EnclosureLength,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostByGuid = `-- name: GetPostByGuid :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content, guid, author, content_encoded, duration_seconds, episode, season, image_url FROM posts WHERE feed_id = $1 AND guid = $2
+`
+
+type GetPostByGuidParams struct {
+	FeedID uuid.UUID
+	Guid   string
+}
+
+func (q *Queries) GetPostByGuid(ctx context.Context, arg GetPostByGuidParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByGuid, arg.FeedID, arg.Guid)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -56,14 +167,138 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Content,
+		&i.Guid,
+		&i.Author,
+		&i.ContentEncoded,
+		&i.DurationSeconds,
+		&i.Episode,
+		&i.Season,
+		&i.ImageUrl,
 	)
 	return i, err
 }
 
+const getPostCategories = `-- name: GetPostCategories :many
+SELECT name FROM post_categories
+WHERE post_id = $1
+ORDER BY name
+`
+
+func (q *Queries) GetPostCategories(ctx context.Context, postID uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getPostCategories, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostEnclosures = `-- name: GetPostEnclosures :many
+SELECT post_id, url, type, length FROM post_enclosures
+WHERE post_id = $1
+`
+
+func (q *Queries) GetPostEnclosures(ctx context.Context, postID uuid.UUID) ([]PostEnclosure, error) {
+	rows, err := q.db.QueryContext(ctx, getPostEnclosures, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostEnclosure
+	for rows.Next() {
+		var i PostEnclosure
+		if err := rows.Scan(
+			&i.PostID,
+			&i.Url,
+			&i.Type,
+			&i.Length,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsByIDPrefix = `-- name: GetPostsByIDPrefix :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.guid, posts.author, posts.content_encoded, posts.duration_seconds, posts.episode, posts.season, posts.image_url FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+  AND posts.id::text LIKE $2::text || '%'
+LIMIT 2
+`
+
+type GetPostsByIDPrefixParams struct {
+	UserID uuid.UUID
+	Prefix string
+}
+
+func (q *Queries) GetPostsByIDPrefix(ctx context.Context, arg GetPostsByIDPrefixParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsByIDPrefix, arg.UserID, arg.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+			&i.Guid,
+			&i.Author,
+			&i.ContentEncoded,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name FROM posts
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.guid, posts.author, posts.content_encoded, posts.duration_seconds, posts.episode, posts.season, posts.image_url, feeds.name AS feed_name, post_states.read_at, post_states.starred_at FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
 WHERE feed_follows.user_id = $1
 ORDER BY posts.published_at DESC
 LIMIT $2
@@ -75,15 +310,25 @@ type GetPostsForUserParams struct {
 }
 
 type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-	FeedName    string
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	Content         sql.NullString
+	Guid            string
+	Author          sql.NullString
+	ContentEncoded  sql.NullString
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+	FeedName        string
+	ReadAt          sql.NullTime
+	StarredAt       sql.NullTime
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
@@ -104,7 +349,201 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Content,
+			&i.Guid,
+			&i.Author,
+			&i.ContentEncoded,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
+			&i.FeedName,
+			&i.ReadAt,
+			&i.StarredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserFeed = `-- name: GetPostsForUserFeed :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.guid, posts.author, posts.content_encoded, posts.duration_seconds, posts.episode, posts.season, posts.image_url, feeds.name AS feed_name, post_states.read_at, post_states.starred_at FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.feed_id = $2
+ORDER BY posts.published_at DESC
+LIMIT $3
+`
+
+type GetPostsForUserFeedParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+	Limit  int32
+}
+
+type GetPostsForUserFeedRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	Content         sql.NullString
+	Guid            string
+	Author          sql.NullString
+	ContentEncoded  sql.NullString
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+	FeedName        string
+	ReadAt          sql.NullTime
+	StarredAt       sql.NullTime
+}
+
+func (q *Queries) GetPostsForUserFeed(ctx context.Context, arg GetPostsForUserFeedParams) ([]GetPostsForUserFeedRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserFeed, arg.UserID, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserFeedRow
+	for rows.Next() {
+		var i GetPostsForUserFeedRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+			&i.Guid,
+			&i.Author,
+			&i.ContentEncoded,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
 			&i.FeedName,
+			&i.ReadAt,
+			&i.StarredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostCategories = `-- name: ListPostCategories :many
+SELECT post_id, name FROM post_categories ORDER BY post_id, name
+`
+
+func (q *Queries) ListPostCategories(ctx context.Context) ([]PostCategory, error) {
+	rows, err := q.db.QueryContext(ctx, listPostCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostCategory
+	for rows.Next() {
+		var i PostCategory
+		if err := rows.Scan(&i.PostID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostEnclosures = `-- name: ListPostEnclosures :many
+SELECT post_id, url, type, length FROM post_enclosures ORDER BY post_id, url
+`
+
+func (q *Queries) ListPostEnclosures(ctx context.Context) ([]PostEnclosure, error) {
+	rows, err := q.db.QueryContext(ctx, listPostEnclosures)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostEnclosure
+	for rows.Next() {
+		var i PostEnclosure
+		if err := rows.Scan(
+			&i.PostID,
+			&i.Url,
+			&i.Type,
+			&i.Length,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPosts = `-- name: ListPosts :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content, guid, author, content_encoded, duration_seconds, episode, season, image_url FROM posts ORDER BY created_at
+`
+
+func (q *Queries) ListPosts(ctx context.Context) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+			&i.Guid,
+			&i.Author,
+			&i.ContentEncoded,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.Season,
+			&i.ImageUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -118,3 +557,259 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const prunePostsBeyondNewest = `-- name: PrunePostsBeyondNewest :execrows
+DELETE FROM posts
+WHERE posts.feed_id = $1
+    AND posts.id NOT IN (
+        SELECT newest.id FROM posts newest
+        WHERE newest.feed_id = $1
+        ORDER BY COALESCE(newest.published_at, newest.created_at) DESC
+        LIMIT $2::int
+    )
+    -- Starred posts and posts a follower hasn't read yet are always kept.
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.starred_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id
+            AND NOT EXISTS (
+                SELECT 1 FROM post_states
+                WHERE post_states.post_id = posts.id
+                    AND post_states.user_id = feed_follows.user_id
+                    AND post_states.read_at IS NOT NULL
+            )
+    )
+`
+
+type PrunePostsBeyondNewestParams struct {
+	FeedID uuid.UUID
+	Keep   int32
+}
+
+func (q *Queries) PrunePostsBeyondNewest(ctx context.Context, arg PrunePostsBeyondNewestParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, prunePostsBeyondNewest, arg.FeedID, arg.Keep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const prunePostsOlderThan = `-- name: PrunePostsOlderThan :execrows
+DELETE FROM posts
+WHERE posts.feed_id = $1
+    AND COALESCE(posts.published_at, posts.created_at) < $2::timestamp
+    -- Starred posts and posts a follower hasn't read yet are always kept.
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.starred_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id
+            AND NOT EXISTS (
+                SELECT 1 FROM post_states
+                WHERE post_states.post_id = posts.id
+                    AND post_states.user_id = feed_follows.user_id
+                    AND post_states.read_at IS NOT NULL
+            )
+    )
+`
+
+type PrunePostsOlderThanParams struct {
+	FeedID uuid.UUID
+	Cutoff time.Time
+}
+
+func (q *Queries) PrunePostsOlderThan(ctx context.Context, arg PrunePostsOlderThanParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, prunePostsOlderThan, arg.FeedID, arg.Cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rekeyLegacyPost = `-- name: RekeyLegacyPost :exec
+UPDATE posts SET guid = $1
+WHERE posts.feed_id = $2
+  AND posts.guid = $3
+  AND posts.url = $3
+  AND $1 NOT IN (
+      SELECT other.guid FROM posts AS other WHERE other.feed_id = $2
+  )
+`
+
+type RekeyLegacyPostParams struct {
+	Guid   string
+	FeedID uuid.UUID
+	Url    string
+}
+
+// Posts stored before guids were tracked were keyed by their url. Such a
+// post takes on its item's real guid the next time the item is fetched, so
+// the upsert updates it instead of adding a duplicate.
+func (q *Queries) RekeyLegacyPost(ctx context.Context, arg RekeyLegacyPostParams) error {
+	_, err := q.db.ExecContext(ctx, rekeyLegacyPost, arg.Guid, arg.FeedID, arg.Url)
+	return err
+}
+
+const restorePost = `-- name: RestorePost :execrows
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content, guid, author, content_encoded, duration_seconds, episode, season, image_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+ON CONFLICT DO NOTHING
+`
+
+type RestorePostParams struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	Content         sql.NullString
+	Guid            string
+	Author          sql.NullString
+	ContentEncoded  sql.NullString
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+}
+
+func (q *Queries) RestorePost(ctx context.Context, arg RestorePostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restorePost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Content,
+		arg.Guid,
+		arg.Author,
+		arg.ContentEncoded,
+		arg.DurationSeconds,
+		arg.Episode,
+		arg.Season,
+		arg.ImageUrl,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updatePostContent = `-- name: UpdatePostContent :exec
+UPDATE posts
+SET content = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdatePostContentParams struct {
+	ID      uuid.UUID
+	Content sql.NullString
+}
+
+func (q *Queries) UpdatePostContent(ctx context.Context, arg UpdatePostContentParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostContent, arg.ID, arg.Content)
+	return err
+}
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO posts (id,  title, url, description, published_at, feed_id, guid, author, content_encoded, duration_seconds, episode, season, image_url, created_at, updated_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    NOW(),
+    NOW()
+)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    published_at = EXCLUDED.published_at,
+    author = EXCLUDED.author,
+    content_encoded = EXCLUDED.content_encoded,
+    duration_seconds = EXCLUDED.duration_seconds,
+    episode = EXCLUDED.episode,
+    season = EXCLUDED.season,
+    image_url = EXCLUDED.image_url,
+    content = CASE WHEN posts.url = EXCLUDED.url THEN posts.content END,
+    updated_at = NOW()
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+    OR posts.url IS DISTINCT FROM EXCLUDED.url
+    OR posts.description IS DISTINCT FROM EXCLUDED.description
+    OR posts.content_encoded IS DISTINCT FROM EXCLUDED.content_encoded
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, content, guid, author, content_encoded, duration_seconds, episode, season, image_url
+`
+
+type UpsertPostParams struct {
+	ID              uuid.UUID
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	Guid            string
+	Author          sql.NullString
+	ContentEncoded  sql.NullString
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+}
+
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Guid,
+		arg.Author,
+		arg.ContentEncoded,
+		arg.DurationSeconds,
+		arg.Episode,
+		arg.Season,
+		arg.ImageUrl,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Content,
+		&i.Guid,
+		&i.Author,
+		&i.ContentEncoded,
+		&i.DurationSeconds,
+		&i.Episode,
+		&i.Season,
+		&i.ImageUrl,
+	)
+	return i, err
+}
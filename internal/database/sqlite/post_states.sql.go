@@ -0,0 +1,132 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: post_states.sql
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const listPostStates = `-- name: ListPostStates :many
+SELECT user_id, post_id, read_at, starred_at FROM post_states
+`
+
+func (q *Queries) ListPostStates(ctx context.Context) ([]PostState, error) {
+	rows, err := q.db.QueryContext(ctx, listPostStates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostState
+	for rows.Next() {
+		var i PostState
+		if err := rows.Scan(
+			&i.UserID,
+			&i.PostID,
+			&i.ReadAt,
+			&i.StarredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markPostRead = `-- name: MarkPostRead :exec
+INSERT INTO post_states (user_id, post_id, read_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id, post_id) DO UPDATE SET read_at = CURRENT_TIMESTAMP
+`
+
+type MarkPostReadParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) MarkPostRead(ctx context.Context, arg MarkPostReadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostRead, arg.UserID, arg.PostID)
+	return err
+}
+
+const markPostStarred = `-- name: MarkPostStarred :exec
+INSERT INTO post_states (user_id, post_id, starred_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id, post_id) DO UPDATE SET starred_at = CURRENT_TIMESTAMP
+`
+
+type MarkPostStarredParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) MarkPostStarred(ctx context.Context, arg MarkPostStarredParams) error {
+	_, err := q.db.ExecContext(ctx, markPostStarred, arg.UserID, arg.PostID)
+	return err
+}
+
+const markPostUnread = `-- name: MarkPostUnread :exec
+UPDATE post_states SET read_at = NULL
+WHERE user_id = ? AND post_id = ?
+`
+
+type MarkPostUnreadParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) MarkPostUnread(ctx context.Context, arg MarkPostUnreadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostUnread, arg.UserID, arg.PostID)
+	return err
+}
+
+const markPostUnstarred = `-- name: MarkPostUnstarred :exec
+UPDATE post_states SET starred_at = NULL
+WHERE user_id = ? AND post_id = ?
+`
+
+type MarkPostUnstarredParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) MarkPostUnstarred(ctx context.Context, arg MarkPostUnstarredParams) error {
+	_, err := q.db.ExecContext(ctx, markPostUnstarred, arg.UserID, arg.PostID)
+	return err
+}
+
+const restorePostState = `-- name: RestorePostState :exec
+INSERT INTO post_states (user_id, post_id, read_at, starred_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    starred_at = COALESCE(post_states.starred_at, EXCLUDED.starred_at)
+`
+
+type RestorePostStateParams struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	ReadAt    sql.NullTime
+	StarredAt sql.NullTime
+}
+
+func (q *Queries) RestorePostState(ctx context.Context, arg RestorePostStateParams) error {
+	_, err := q.db.ExecContext(ctx, restorePostState,
+		arg.UserID,
+		arg.PostID,
+		arg.ReadAt,
+		arg.StarredAt,
+	)
+	return err
+}
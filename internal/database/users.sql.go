@@ -12,15 +12,27 @@ import (
 	"github.com/google/uuid"
 )
 
+const countAdmins = `-- name: CountAdmins :one
+SELECT COUNT(*) FROM users WHERE is_admin
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, is_admin)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    NOT EXISTS (SELECT 1 FROM users)
 )
-RETURNING id, created_at, updated_at, name
+RETURNING id, created_at, updated_at, name, is_admin
 `
 
 type CreateUserParams struct {
@@ -30,6 +42,7 @@ type CreateUserParams struct {
 	Name      string
 }
 
+// The first user to register becomes the admin.
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, createUser,
 		arg.ID,
@@ -43,12 +56,22 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, name FROM users WHERE name = $1 LIMIT 1
+SELECT id, created_at, updated_at, name, is_admin FROM users WHERE name = $1 LIMIT 1
 `
 
 func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
@@ -59,6 +82,7 @@ func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.IsAdmin,
 	)
 	return i, err
 }
@@ -101,6 +125,48 @@ func (q *Queries) GetUsers(ctx context.Context) ([]string, error) {
 	return items, nil
 }
 
+const listUsers = `-- name: ListUsers :many
+SELECT id, created_at, updated_at, name, is_admin FROM users ORDER BY created_at
+`
+
+func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.IsAdmin,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const promoteUser = `-- name: PromoteUser :exec
+UPDATE users SET is_admin = TRUE, updated_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) PromoteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, promoteUser, id)
+	return err
+}
+
 const resetDatabase = `-- name: ResetDatabase :exec
 DELETE FROM users
 `
@@ -109,3 +175,31 @@ func (q *Queries) ResetDatabase(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetDatabase)
 	return err
 }
+
+const restoreUser = `-- name: RestoreUser :execrows
+INSERT INTO users (id, created_at, updated_at, name, is_admin)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING
+`
+
+type RestoreUserParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string
+	IsAdmin   bool
+}
+
+func (q *Queries) RestoreUser(ctx context.Context, arg RestoreUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreUser,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Name,
+		arg.IsAdmin,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,327 @@
+// Package htmltext renders feed HTML as plain text for the terminal.
+package htmltext
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Render converts an HTML fragment into plain text wrapped at width columns.
+// Markup that has no place in a terminal (scripts, styles, embeds) is
+// dropped, control characters are stripped, and links and images are
+// replaced by numbered markers that are listed as footnotes after the text.
+func Render(src string, width int) string {
+	body := &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"}
+	nodes, err := html.ParseFragment(strings.NewReader(src), body)
+	if err != nil {
+		return Wrap(src, width)
+	}
+
+	r := &renderer{linkIndex: map[string]int{}}
+	for _, n := range nodes {
+		r.walk(n)
+	}
+	r.flush(false)
+
+	out := r.layout(width)
+	if len(r.links) > 0 {
+		var notes []string
+		for i, link := range r.links {
+			notes = append(notes, fmt.Sprintf("[%d] %s", i+1, link))
+		}
+		out += "\n\n" + strings.Join(notes, "\n")
+	}
+	return out
+}
+
+// Wrap wraps plain text at width columns, keeping its existing line and
+// paragraph breaks and stripping control characters.
+func Wrap(text string, width int) string {
+	lines := strings.Split(sanitize(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(wrapWords(line, width), "\n")
+	}
+	return strings.Join(lines, "\n")
+}
+
+type block struct {
+	quote  string
+	indent string
+	marker string
+	text   string
+	pre    bool
+	tight  bool // follows the previous block without a blank line
+}
+
+type renderer struct {
+	blocks []block
+	cur    strings.Builder
+
+	quote     string
+	marker    string
+	listDepth int
+	pre       int
+	tight     bool
+
+	links     []string
+	linkIndex map[string]int
+}
+
+func (r *renderer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.cur.WriteString(sanitize(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			r.walk(c)
+		}
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Iframe, atom.Object, atom.Embed,
+		atom.Noscript, atom.Svg, atom.Form, atom.Button, atom.Select, atom.Template:
+		return
+
+	case atom.Br:
+		r.flush(true)
+		return
+
+	case atom.Hr:
+		r.flush(false)
+		r.blocks = append(r.blocks, block{text: "---"})
+		return
+
+	case atom.Img:
+		alt := strings.TrimSpace(attr(n, "alt"))
+		label := "[image]"
+		if alt != "" {
+			label = "[image: " + sanitize(alt) + "]"
+		}
+		r.cur.WriteString(label)
+		if src := attr(n, "src"); src != "" {
+			r.cur.WriteString(r.footnote(src))
+		}
+		return
+
+	case atom.A:
+		r.walkChildren(n)
+		href := strings.TrimSpace(attr(n, "href"))
+		if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			r.cur.WriteString(r.footnote(href))
+		}
+		return
+
+	case atom.Ul, atom.Ol:
+		// Nested lists hug the item they belong to; top level lists are
+		// set apart from the surrounding paragraphs.
+		nested := r.listDepth > 0
+		r.flush(nested)
+		r.listDepth++
+		i := 1
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == atom.Li {
+				r.marker = "- "
+				if n.DataAtom == atom.Ol {
+					r.marker = fmt.Sprintf("%d. ", i)
+				}
+				i++
+				r.walkChildren(c)
+				r.flush(true)
+				continue
+			}
+			r.walk(c)
+		}
+		r.listDepth--
+		r.flush(nested)
+		return
+
+	case atom.Blockquote:
+		r.flush(false)
+		saved := r.quote
+		r.quote += "> "
+		r.walkChildren(n)
+		r.flush(false)
+		r.quote = saved
+		return
+
+	case atom.Pre:
+		r.flush(false)
+		r.pre++
+		r.walkChildren(n)
+		r.flush(false)
+		r.pre--
+		return
+	}
+
+	if isBlock(n.DataAtom) {
+		r.flush(false)
+		r.walkChildren(n)
+		r.flush(false)
+		return
+	}
+
+	r.walkChildren(n)
+}
+
+func (r *renderer) walkChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+}
+
+// flush ends the current block of inline text. When tight is set (line
+// breaks and list items) the next block follows without a blank line.
+func (r *renderer) flush(tight bool) {
+	text := r.cur.String()
+	r.cur.Reset()
+	defer func() { r.tight = tight }()
+
+	if r.pre > 0 {
+		text = strings.Trim(text, "\n")
+	} else {
+		text = strings.Join(strings.Fields(text), " ")
+	}
+	if text == "" {
+		// An empty block can still end a run of tight blocks.
+		tight = tight && r.tight
+		return
+	}
+
+	indent := ""
+	if r.listDepth > 1 {
+		indent = strings.Repeat("  ", r.listDepth-1)
+	}
+	r.blocks = append(r.blocks, block{
+		quote:  r.quote,
+		indent: indent,
+		marker: r.marker,
+		text:   text,
+		pre:    r.pre > 0,
+		tight:  r.tight,
+	})
+	r.marker = ""
+}
+
+func (r *renderer) footnote(link string) string {
+	i, ok := r.linkIndex[link]
+	if !ok {
+		r.links = append(r.links, sanitize(link))
+		i = len(r.links)
+		r.linkIndex[link] = i
+	}
+	return fmt.Sprintf("[%d]", i)
+}
+
+func (r *renderer) layout(width int) string {
+	var b strings.Builder
+	for i, blk := range r.blocks {
+		if i > 0 {
+			b.WriteString("\n")
+			if !blk.tight {
+				b.WriteString("\n")
+			}
+		}
+
+		first := blk.quote + blk.indent + blk.marker
+		rest := blk.quote + blk.indent + strings.Repeat(" ", len(blk.marker))
+
+		var lines []string
+		if blk.pre {
+			lines = strings.Split(blk.text, "\n")
+		} else {
+			lines = wrapWords(blk.text, width-runewidth.StringWidth(first))
+		}
+		for j, line := range lines {
+			if j > 0 {
+				b.WriteString("\n")
+				b.WriteString(rest)
+			} else {
+				b.WriteString(first)
+			}
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// ===== Helper Functions =====
+
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header,
+		atom.Footer, atom.Aside, atom.Nav, atom.Table, atom.Tr, atom.Figure,
+		atom.Figcaption, atom.Dl, atom.Dt, atom.Dd, atom.Li,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+	return false
+}
+
+// wrapWords greedily wraps a single line of text. Words longer than width
+// are left on a line of their own rather than split.
+func wrapWords(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	var lines []string
+	line := words[0]
+	lineWidth := runewidth.StringWidth(line)
+	for _, word := range words[1:] {
+		w := runewidth.StringWidth(word)
+		if lineWidth+1+w > width {
+			lines = append(lines, line)
+			line, lineWidth = word, w
+			continue
+		}
+		line += " " + word
+		lineWidth += 1 + w
+	}
+	return append(lines, line)
+}
+
+// Line strips control characters, newlines and tabs included, from a single
+// line of feed text such as a title, feed name or url, so it can't smuggle
+// terminal escape sequences or break the layout it is printed in.
+func Line(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || (r >= 0x7f && r < 0xa0) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// sanitize removes control characters, including terminal escape sequences
+// smuggled into feed text, keeping newlines and tabs.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case r < 0x20 || (r >= 0x7f && r < 0xa0):
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
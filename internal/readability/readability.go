@@ -0,0 +1,264 @@
+// Package readability pulls the main article text out of a web page, in the
+// spirit of Arc90's Readability: paragraphs vote for the container they sit
+// in and the best scoring container is rendered as plain text.
+package readability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const maxPageSize = 5 << 20
+
+var ErrNoContent = errors.New("no readable content found")
+
+// Fetch downloads the page at pageURL with client and extracts its article
+// text.
+func Fetch(ctx context.Context, client *http.Client, userAgent, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Add("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error getting page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error getting page: %s", resp.Status)
+	}
+
+	return Extract(io.LimitReader(resp.Body, maxPageSize))
+}
+
+// Extract parses an HTML document and returns the text of its main content,
+// with paragraphs separated by blank lines.
+func Extract(r io.Reader) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", fmt.Errorf("error parsing html: %w", err)
+	}
+
+	removeUnlikely(doc)
+
+	best := bestCandidate(doc)
+	if best == nil {
+		return "", ErrNoContent
+	}
+
+	text := renderText(best)
+	if text == "" {
+		return "", ErrNoContent
+	}
+	return text, nil
+}
+
+// ===== Scoring =====
+
+var (
+	positiveHint = regexp.MustCompile(`(?i)article|body|content|entry|hentry|main|page|post|text|blog|story`)
+	negativeHint = regexp.MustCompile(`(?i)comment|meta|footer|footnote|sidebar|sponsor|shoutbox|share|social|related|promo|banner|nav|menu|widget|popup|cookie|newsletter|subscribe`)
+	unlikelyHint = regexp.MustCompile(`(?i)combx|comment|disqus|foot|header|menu|rss|shoutbox|sidebar|sponsor|ad-break|agegate|pagination|pager|popup|cookie`)
+	maybeHint    = regexp.MustCompile(`(?i)and|article|body|column|main|shadow`)
+)
+
+// removeUnlikely strips elements that never hold article text, along with
+// anything whose class or id marks it as page chrome.
+func removeUnlikely(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && isUnlikely(c) {
+			n.RemoveChild(c)
+		} else if c.Type == html.CommentNode {
+			n.RemoveChild(c)
+		} else {
+			removeUnlikely(c)
+		}
+		c = next
+	}
+}
+
+func isUnlikely(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Nav, atom.Aside, atom.Form,
+		atom.Iframe, atom.Button, atom.Select, atom.Svg, atom.Footer, atom.Header:
+		return true
+	case atom.Html, atom.Body, atom.Article, atom.Main:
+		return false
+	}
+
+	hint := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyHint.MatchString(hint) && !maybeHint.MatchString(hint)
+}
+
+func bestCandidate(doc *html.Node) *html.Node {
+	scores := map[*html.Node]float64{}
+	var order []*html.Node
+
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = classWeight(n)
+			order = append(order, n)
+		}
+		scores[n] += score
+	}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.DataAtom == atom.P || n.DataAtom == atom.Pre || n.DataAtom == atom.Td) {
+			text := collapseSpace(textContent(n))
+			if len(text) >= 25 {
+				score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text)/100), 3)
+				addScore(n.Parent, score)
+				if n.Parent != nil {
+					addScore(n.Parent.Parent, score/2)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range order {
+		score := scores[n] * (1 - linkDensity(n))
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, hint := range []string{attr(n, "class"), attr(n, "id")} {
+		if hint == "" {
+			continue
+		}
+		if negativeHint.MatchString(hint) {
+			weight -= 25
+		}
+		if positiveHint.MatchString(hint) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := len(textContent(n))
+	if total == 0 {
+		return 0
+	}
+
+	linked := 0
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			linked += len(textContent(c))
+			return
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			walk(cc)
+		}
+	}
+	walk(n)
+
+	return float64(linked) / float64(total)
+}
+
+// ===== Rendering =====
+
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Blockquote,
+		atom.Pre, atom.Ul, atom.Ol, atom.Li, atom.Table, atom.Tr, atom.Figure,
+		atom.Figcaption, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Hr:
+		return true
+	}
+	return false
+}
+
+// renderText flattens n into paragraphs, breaking at block elements.
+func renderText(n *html.Node) string {
+	var paragraphs []string
+	var current strings.Builder
+
+	flush := func() {
+		if text := collapseSpace(current.String()); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		current.Reset()
+	}
+
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		switch c.Type {
+		case html.TextNode:
+			current.WriteString(c.Data)
+			return
+		case html.ElementNode:
+			if c.DataAtom == atom.Br {
+				flush()
+				return
+			}
+			if isBlock(c.DataAtom) {
+				flush()
+				if c.DataAtom == atom.Li {
+					current.WriteString("- ")
+				}
+				defer flush()
+			}
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			walk(cc)
+		}
+	}
+	walk(n)
+	flush()
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// ===== Helper Functions =====
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
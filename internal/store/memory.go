@@ -0,0 +1,1171 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps everything in process memory. It follows the same
+// constraints as the SQL schema (unique names and urls, foreign keys with
+// cascading deletes) so handlers can be exercised without a database.
+type memoryStore struct {
+	mu   *sync.Mutex
+	txMu *sync.Mutex
+	data *memoryData
+}
+
+type memoryData struct {
+	users      []database.User
+	feeds      []database.Feed
+	follows    []database.FeedFollow
+	posts      []database.Post
+	states     []database.PostState
+	categories []database.PostCategory
+	enclosures []database.PostEnclosure
+}
+
+var _ Store = (*memoryStore)(nil)
+
+// NewMemory returns an empty Store that lives only as long as the process.
+func NewMemory() Store {
+	return &memoryStore{mu: &sync.Mutex{}, txMu: &sync.Mutex{}, data: &memoryData{}}
+}
+
+// InTx runs fn against a copy of the data and keeps the copy only if fn
+// succeeds. Transactions run one at a time; writes made outside a
+// transaction while one is open are lost when it commits.
+func (s *memoryStore) InTx(ctx context.Context, fn func(Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	snapshot := s.data.clone()
+	s.mu.Unlock()
+
+	tx := &memoryStore{mu: &sync.Mutex{}, txMu: &sync.Mutex{}, data: snapshot}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.data = tx.data
+	s.mu.Unlock()
+	return nil
+}
+
+// InReadTx runs fn against a copy of the data taken when it starts, and
+// throws the copy away afterwards.
+func (s *memoryStore) InReadTx(ctx context.Context, fn func(Store) error) error {
+	s.mu.Lock()
+	snapshot := s.data.clone()
+	s.mu.Unlock()
+
+	return fn(&memoryStore{mu: &sync.Mutex{}, txMu: &sync.Mutex{}, data: snapshot})
+}
+
+// ===== Users =====
+
+func (s *memoryStore) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.data.users, func(u database.User) bool { return u.ID == arg.ID }) {
+		return database.User{}, uniqueViolation("users_pkey")
+	}
+	if slices.ContainsFunc(s.data.users, func(u database.User) bool { return u.Name == arg.Name }) {
+		return database.User{}, uniqueViolation("users_name_key")
+	}
+
+	user := database.User{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+		IsAdmin:   len(s.data.users) == 0,
+	}
+	s.data.users = append(s.data.users, user)
+	return user, nil
+}
+
+func (s *memoryStore) GetUser(ctx context.Context, name string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return findOne(s.data.users, func(u database.User) bool { return u.Name == name })
+}
+
+func (s *memoryStore) GetUserName(ctx context.Context, id uuid.UUID) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, err := findOne(s.data.users, func(u database.User) bool { return u.ID == id })
+	return user.Name, err
+}
+
+func (s *memoryStore) GetUsers(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for _, user := range s.data.users {
+		names = append(names, user.Name)
+	}
+	return names, nil
+}
+
+func (s *memoryStore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.deleteUsers(func(u database.User) bool { return u.ID == id })
+	return nil
+}
+
+func (s *memoryStore) CountAdmins(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, u := range s.data.users {
+		if u.IsAdmin {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memoryStore) PromoteUser(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.users {
+		if s.data.users[i].ID == id {
+			s.data.users[i].IsAdmin = true
+			s.data.users[i].UpdatedAt = time.Now().UTC()
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) ListUsers(ctx context.Context) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.data.users), nil
+}
+
+func (s *memoryStore) RestoreUser(ctx context.Context, arg database.RestoreUserParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.ContainsFunc(s.data.users, func(u database.User) bool { return u.ID == arg.ID || u.Name == arg.Name }) {
+		return 0, nil
+	}
+	s.data.users = append(s.data.users, database.User(arg))
+	return 1, nil
+}
+
+func (s *memoryStore) ResetDatabase(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.deleteUsers(func(database.User) bool { return true })
+	return nil
+}
+
+// ===== Feeds =====
+
+func (s *memoryStore) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.data.feeds, func(f database.Feed) bool { return f.ID == arg.ID }) {
+		return database.Feed{}, uniqueViolation("feeds_pkey")
+	}
+	if slices.ContainsFunc(s.data.feeds, func(f database.Feed) bool { return f.Url == arg.Url }) {
+		return database.Feed{}, uniqueViolation("feeds_url_key")
+	}
+	if !s.data.hasUser(arg.UserID) {
+		return database.Feed{}, foreignKeyViolation("feeds_user_id_fkey")
+	}
+
+	feed := database.Feed{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+		Url:       arg.Url,
+		UserID:    arg.UserID,
+	}
+	s.data.feeds = append(s.data.feeds, feed)
+	return feed, nil
+}
+
+func (s *memoryStore) GetFeed(ctx context.Context, url string) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return findOne(s.data.feeds, func(f database.Feed) bool { return f.Url == url })
+}
+
+func (s *memoryStore) GetFeedByID(ctx context.Context, id uuid.UUID) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return findOne(s.data.feeds, func(f database.Feed) bool { return f.ID == id })
+}
+
+func (s *memoryStore) GetFeeds(ctx context.Context) ([]database.GetFeedsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetFeedsRow
+	for _, feed := range s.data.feeds {
+		rows = append(rows, database.GetFeedsRow{Name: feed.Name, Url: feed.Url, UserID: feed.UserID, DeadAt: feed.DeadAt, ParseWarning: feed.ParseWarning})
+	}
+	return rows, nil
+}
+
+func (s *memoryStore) GetNextFeedToFetch(ctx context.Context) (database.GetNextFeedToFetchRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	var due []database.Feed
+	for _, feed := range s.data.feeds {
+		if !feed.DeadAt.Valid && (!feed.NextFetchAt.Valid || !feed.NextFetchAt.Time.After(now)) {
+			due = append(due, feed)
+		}
+	}
+	if len(due) == 0 {
+		return database.GetNextFeedToFetchRow{}, sql.ErrNoRows
+	}
+
+	// ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+	feed := slices.MinFunc(due, func(a, b database.Feed) int {
+		if c := compareNullTime(a.NextFetchAt, b.NextFetchAt); c != 0 {
+			return c
+		}
+		return compareNullTime(a.LastFetchedAt, b.LastFetchedAt)
+	})
+	dueSince := feed.UpdatedAt
+	if feed.NextFetchAt.Valid {
+		dueSince = feed.NextFetchAt.Time
+	}
+	return database.GetNextFeedToFetchRow{Feed: feed, DueSeconds: now.Sub(dueSince).Seconds()}, nil
+}
+
+func (s *memoryStore) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	for i := range s.data.feeds {
+		if s.data.feeds[i].ID == arg.ID {
+			s.data.feeds[i].UpdatedAt = now
+			s.data.feeds[i].LastFetchedAt = sql.NullTime{Time: now, Valid: true}
+			s.data.feeds[i].NextFetchAt = sql.NullTime{Time: now.Add(time.Duration(arg.IntervalSeconds) * time.Second), Valid: true}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) DeferFeedFetch(ctx context.Context, arg database.DeferFeedFetchParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := time.Now().UTC().Add(time.Duration(arg.DelaySeconds) * time.Second)
+	for i := range s.data.feeds {
+		if s.data.feeds[i].ID == arg.ID {
+			s.data.feeds[i].NextFetchAt = sql.NullTime{Time: next, Valid: true}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) SetFeedFetchInterval(ctx context.Context, arg database.SetFeedFetchIntervalParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.feeds {
+		if s.data.feeds[i].ID == arg.ID {
+			s.data.feeds[i].FetchIntervalSeconds = arg.FetchIntervalSeconds
+			s.data.feeds[i].NextFetchAt = sql.NullTime{}
+			s.data.feeds[i].DeadAt = sql.NullTime{}
+			s.data.feeds[i].UpdatedAt = time.Now().UTC()
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) SetFeedUrl(ctx context.Context, arg database.SetFeedUrlParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.ContainsFunc(s.data.feeds, func(f database.Feed) bool { return f.Url == arg.Url && f.ID != arg.ID }) {
+		return uniqueViolation("feeds_url_key")
+	}
+	for i := range s.data.feeds {
+		if s.data.feeds[i].ID == arg.ID {
+			s.data.feeds[i].Url = arg.Url
+			s.data.feeds[i].UpdatedAt = time.Now().UTC()
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) MarkFeedDead(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	for i := range s.data.feeds {
+		if s.data.feeds[i].ID == id {
+			s.data.feeds[i].DeadAt = sql.NullTime{Time: now, Valid: true}
+			s.data.feeds[i].UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) ReviveFeed(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.feeds {
+		if s.data.feeds[i].ID == id {
+			s.data.feeds[i].DeadAt = sql.NullTime{}
+			s.data.feeds[i].NextFetchAt = sql.NullTime{}
+			s.data.feeds[i].UpdatedAt = time.Now().UTC()
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) SetFeedParseWarning(ctx context.Context, arg database.SetFeedParseWarningParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.feeds {
+		if s.data.feeds[i].ID == arg.ID {
+			s.data.feeds[i].ParseWarning = arg.ParseWarning
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) SetFeedAuth(ctx context.Context, arg database.SetFeedAuthParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.feeds {
+		if s.data.feeds[i].ID == arg.ID {
+			s.data.feeds[i].Auth = arg.Auth
+			s.data.feeds[i].UpdatedAt = time.Now().UTC()
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) MoveFeedFollows(ctx context.Context, arg database.MoveFeedFollowsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, ff := range s.data.follows {
+		if ff.FeedID != arg.FromFeedID {
+			continue
+		}
+		if !slices.ContainsFunc(s.data.follows, func(other database.FeedFollow) bool {
+			return other.FeedID == arg.ToFeedID && other.UserID == ff.UserID
+		}) {
+			s.data.follows[i].FeedID = arg.ToFeedID
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) MoveFeedPostStates(ctx context.Context, arg database.MoveFeedPostStatesParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guids := map[string]uuid.UUID{}
+	for _, p := range s.data.posts {
+		if p.FeedID == arg.ToFeedID {
+			guids[p.Guid] = p.ID
+		}
+	}
+	for _, source := range s.data.posts {
+		target, ok := guids[source.Guid]
+		if source.FeedID != arg.FromFeedID || !ok {
+			continue
+		}
+		for _, ps := range slices.Clone(s.data.states) {
+			if ps.PostID != source.ID {
+				continue
+			}
+			i := s.data.stateIndex(ps.UserID, target)
+			if i < 0 {
+				s.data.states = append(s.data.states, database.PostState{UserID: ps.UserID, PostID: target, ReadAt: ps.ReadAt, StarredAt: ps.StarredAt})
+				continue
+			}
+			if !s.data.states[i].ReadAt.Valid {
+				s.data.states[i].ReadAt = ps.ReadAt
+			}
+			if !s.data.states[i].StarredAt.Valid {
+				s.data.states[i].StarredAt = ps.StarredAt
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) MoveFeedPosts(ctx context.Context, arg database.MoveFeedPostsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, p := range s.data.posts {
+		if p.FeedID != arg.FromFeedID {
+			continue
+		}
+		if !slices.ContainsFunc(s.data.posts, func(other database.Post) bool {
+			return other.FeedID == arg.ToFeedID && other.Guid == p.Guid
+		}) {
+			s.data.posts[i].FeedID = arg.ToFeedID
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) GetRecentPostTimes(ctx context.Context, arg database.GetRecentPostTimesParams) ([]sql.NullTime, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var times []sql.NullTime
+	for _, post := range s.data.posts {
+		if post.FeedID == arg.FeedID && post.PublishedAt.Valid {
+			times = append(times, post.PublishedAt)
+		}
+	}
+	slices.SortFunc(times, func(a, b sql.NullTime) int { return b.Time.Compare(a.Time) })
+	return limitRows(times, arg.Limit), nil
+}
+
+func (s *memoryStore) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.deleteFeeds(func(f database.Feed) bool { return f.ID == id })
+	return nil
+}
+
+func (s *memoryStore) CountFeedsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for _, feed := range s.data.feeds {
+		if feed.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memoryStore) DeleteAllFeeds(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.deleteFeeds(func(database.Feed) bool { return true })
+	return nil
+}
+
+func (s *memoryStore) ListFeeds(ctx context.Context) ([]database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.data.feeds), nil
+}
+
+func (s *memoryStore) RestoreFeed(ctx context.Context, arg database.RestoreFeedParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.ContainsFunc(s.data.feeds, func(f database.Feed) bool { return f.ID == arg.ID || f.Url == arg.Url }) {
+		return 0, nil
+	}
+	if !s.data.hasUser(arg.UserID) {
+		return 0, foreignKeyViolation("feeds_user_id_fkey")
+	}
+	s.data.feeds = append(s.data.feeds, database.Feed{
+		ID:                   arg.ID,
+		CreatedAt:            arg.CreatedAt,
+		UpdatedAt:            arg.UpdatedAt,
+		Name:                 arg.Name,
+		Url:                  arg.Url,
+		UserID:               arg.UserID,
+		LastFetchedAt:        arg.LastFetchedAt,
+		FetchIntervalSeconds: arg.FetchIntervalSeconds,
+		NextFetchAt:          arg.NextFetchAt,
+		DeadAt:               arg.DeadAt,
+		ParseWarning:         arg.ParseWarning,
+		Auth:                 arg.Auth,
+	})
+	return 1, nil
+}
+
+// ===== Feed follows =====
+
+func (s *memoryStore) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) ([]database.CreateFeedFollowRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.data.follows, func(ff database.FeedFollow) bool {
+		return ff.UserID == arg.UserID && ff.FeedID == arg.FeedID
+	}) {
+		return nil, uniqueViolation("feed_follows_user_id_feed_id_unique")
+	}
+	user, err := findOne(s.data.users, func(u database.User) bool { return u.ID == arg.UserID })
+	if err != nil {
+		return nil, foreignKeyViolation("feed_follows_user_id_fkey")
+	}
+	feed, err := findOne(s.data.feeds, func(f database.Feed) bool { return f.ID == arg.FeedID })
+	if err != nil {
+		return nil, foreignKeyViolation("feed_follows_feed_id_fkey")
+	}
+
+	now := time.Now().UTC()
+	follow := database.FeedFollow{
+		ID:        arg.ID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+	}
+	s.data.follows = append(s.data.follows, follow)
+
+	return []database.CreateFeedFollowRow{{
+		ID:        follow.ID,
+		CreatedAt: follow.CreatedAt,
+		UpdatedAt: follow.UpdatedAt,
+		UserID:    follow.UserID,
+		FeedID:    follow.FeedID,
+		UserName:  user.Name,
+		FeedName:  feed.Name,
+	}}, nil
+}
+
+func (s *memoryStore) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rows []database.GetFeedFollowsForUserRow
+	for _, follow := range s.data.follows {
+		if follow.UserID != userID {
+			continue
+		}
+		feed, err := findOne(s.data.feeds, func(f database.Feed) bool { return f.ID == follow.FeedID })
+		if err != nil {
+			continue
+		}
+		rows = append(rows, database.GetFeedFollowsForUserRow{ID: feed.ID, Name: feed.Name, Url: feed.Url})
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetFeedFollowsForUserRow) int { return strings.Compare(a.Name, b.Name) })
+	return rows, nil
+}
+
+func (s *memoryStore) UnfollowFeed(ctx context.Context, arg database.UnfollowFeedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.follows = slices.DeleteFunc(s.data.follows, func(ff database.FeedFollow) bool {
+		return ff.UserID == arg.UserID && ff.FeedID == arg.FeedID
+	})
+	return nil
+}
+
+func (s *memoryStore) ListFeedFollows(ctx context.Context) ([]database.FeedFollow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.data.follows), nil
+}
+
+func (s *memoryStore) RestoreFeedFollow(ctx context.Context, arg database.RestoreFeedFollowParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.ContainsFunc(s.data.follows, func(ff database.FeedFollow) bool {
+		return ff.ID == arg.ID || (ff.UserID == arg.UserID && ff.FeedID == arg.FeedID)
+	}) {
+		return nil
+	}
+	if !s.data.hasUser(arg.UserID) {
+		return foreignKeyViolation("feed_follows_user_id_fkey")
+	}
+	if !s.data.hasFeed(arg.FeedID) {
+		return foreignKeyViolation("feed_follows_feed_id_fkey")
+	}
+	s.data.follows = append(s.data.follows, database.FeedFollow(arg))
+	return nil
+}
+
+// ===== Posts =====
+
+// UpsertPost mirrors the ON CONFLICT clause of the SQL query: an existing
+// post is only rewritten when its visible fields changed, and sql.ErrNoRows
+// is returned when nothing did.
+func (s *memoryStore) RekeyLegacyPost(ctx context.Context, arg database.RekeyLegacyPostParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.data.posts, func(p database.Post) bool { return p.FeedID == arg.FeedID && p.Guid == arg.Guid }) {
+		return nil
+	}
+	for i, p := range s.data.posts {
+		if p.FeedID == arg.FeedID && p.Guid == arg.Url && p.Url == arg.Url {
+			s.data.posts[i].Guid = arg.Guid
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) UpsertPost(ctx context.Context, arg database.UpsertPostParams) (database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	i := slices.IndexFunc(s.data.posts, func(p database.Post) bool {
+		return p.FeedID == arg.FeedID && p.Guid == arg.Guid
+	})
+	if i < 0 {
+		if !s.data.hasFeed(arg.FeedID) {
+			return database.Post{}, foreignKeyViolation("posts_feed_id_fkey")
+		}
+		if slices.ContainsFunc(s.data.posts, func(p database.Post) bool { return p.ID == arg.ID }) {
+			return database.Post{}, uniqueViolation("posts_pkey")
+		}
+
+		post := database.Post{
+			ID:              arg.ID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+			Title:           arg.Title,
+			Url:             arg.Url,
+			Description:     arg.Description,
+			PublishedAt:     arg.PublishedAt,
+			FeedID:          arg.FeedID,
+			Guid:            arg.Guid,
+			Author:          arg.Author,
+			ContentEncoded:  arg.ContentEncoded,
+			DurationSeconds: arg.DurationSeconds,
+			Episode:         arg.Episode,
+			Season:          arg.Season,
+			ImageUrl:        arg.ImageUrl,
+		}
+		s.data.posts = append(s.data.posts, post)
+		return post, nil
+	}
+
+	post := &s.data.posts[i]
+	if post.Title == arg.Title && post.Url == arg.Url &&
+		post.Description == arg.Description && post.ContentEncoded == arg.ContentEncoded {
+		return database.Post{}, sql.ErrNoRows
+	}
+
+	if post.Url != arg.Url {
+		post.Content = sql.NullString{}
+	}
+	post.Title = arg.Title
+	post.Url = arg.Url
+	post.Description = arg.Description
+	post.PublishedAt = arg.PublishedAt
+	post.Author = arg.Author
+	post.ContentEncoded = arg.ContentEncoded
+	post.DurationSeconds = arg.DurationSeconds
+	post.Episode = arg.Episode
+	post.Season = arg.Season
+	post.ImageUrl = arg.ImageUrl
+	post.UpdatedAt = now
+	return *post, nil
+}
+
+func (s *memoryStore) UpdatePostContent(ctx context.Context, arg database.UpdatePostContentParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.posts {
+		if s.data.posts[i].ID == arg.ID {
+			s.data.posts[i].Content = arg.Content
+			s.data.posts[i].UpdatedAt = time.Now().UTC()
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) GetPostsByIDPrefix(ctx context.Context, arg database.GetPostsByIDPrefixParams) ([]database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var posts []database.Post
+	for _, post := range s.data.posts {
+		if strings.HasPrefix(post.ID.String(), arg.Prefix) && s.data.isFollowing(arg.UserID, post.FeedID) {
+			posts = append(posts, post)
+			if len(posts) == 2 {
+				break
+			}
+		}
+	}
+	return posts, nil
+}
+
+func (s *memoryStore) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.postsForUser(arg.UserID, uuid.Nil, arg.Limit), nil
+}
+
+func (s *memoryStore) GetPostsForUserFeed(ctx context.Context, arg database.GetPostsForUserFeedParams) ([]database.GetPostsForUserFeedRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := s.data.postsForUser(arg.UserID, arg.FeedID, arg.Limit)
+	return convertAll(rows, func(r database.GetPostsForUserRow) database.GetPostsForUserFeedRow {
+		return database.GetPostsForUserFeedRow(r)
+	}), nil
+}
+
+func (s *memoryStore) GetEpisodesForUser(ctx context.Context, arg database.GetEpisodesForUserParams) ([]database.GetEpisodesForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rows []database.GetEpisodesForUserRow
+	for _, post := range s.data.postsForUser(arg.UserID, uuid.Nil, -1) {
+		// A post is listed once, with its first enclosure by url.
+		var first *database.PostEnclosure
+		for i, enclosure := range s.data.enclosures {
+			if enclosure.PostID == post.ID && (first == nil || enclosure.Url < first.Url) {
+				first = &s.data.enclosures[i]
+			}
+		}
+		if first != nil {
+			enclosure := *first
+			rows = append(rows, database.GetEpisodesForUserRow{
+				ID:              post.ID,
+				Title:           post.Title,
+				PublishedAt:     post.PublishedAt,
+				DurationSeconds: post.DurationSeconds,
+				Episode:         post.Episode,
+				Season:          post.Season,
+				FeedName:        post.FeedName,
+				EnclosureUrl:    enclosure.Url,
+				EnclosureType:   enclosure.Type,
+				EnclosureLength: enclosure.Length,
+			})
+		}
+	}
+	return limitRows(rows, arg.Limit), nil
+}
+
+func (s *memoryStore) DeleteAllPosts(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.deletePosts(func(database.Post) bool { return true })
+	return nil
+}
+
+func (s *memoryStore) ListPosts(ctx context.Context) ([]database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.data.posts), nil
+}
+
+func (s *memoryStore) GetPostByGuid(ctx context.Context, arg database.GetPostByGuidParams) (database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return findOne(s.data.posts, func(p database.Post) bool { return p.FeedID == arg.FeedID && p.Guid == arg.Guid })
+}
+
+func (s *memoryStore) RestorePost(ctx context.Context, arg database.RestorePostParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.ContainsFunc(s.data.posts, func(p database.Post) bool {
+		return p.ID == arg.ID || (p.FeedID == arg.FeedID && p.Guid == arg.Guid)
+	}) {
+		return 0, nil
+	}
+	if !s.data.hasFeed(arg.FeedID) {
+		return 0, foreignKeyViolation("posts_feed_id_fkey")
+	}
+	s.data.posts = append(s.data.posts, database.Post(arg))
+	return 1, nil
+}
+
+func (s *memoryStore) PrunePostsOlderThan(ctx context.Context, arg database.PrunePostsOlderThanParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.deletePosts(func(p database.Post) bool {
+		return p.FeedID == arg.FeedID && postTime(p).Before(arg.Cutoff) && !s.data.keepPost(p)
+	}), nil
+}
+
+func (s *memoryStore) PrunePostsBeyondNewest(ctx context.Context, arg database.PrunePostsBeyondNewestParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var newest []database.Post
+	for _, post := range s.data.posts {
+		if post.FeedID == arg.FeedID {
+			newest = append(newest, post)
+		}
+	}
+	slices.SortStableFunc(newest, func(a, b database.Post) int { return postTime(b).Compare(postTime(a)) })
+	keep := map[uuid.UUID]bool{}
+	for _, post := range limitRows(newest, arg.Keep) {
+		keep[post.ID] = true
+	}
+
+	return s.data.deletePosts(func(p database.Post) bool {
+		return p.FeedID == arg.FeedID && !keep[p.ID] && !s.data.keepPost(p)
+	}), nil
+}
+
+// ===== Post metadata =====
+
+func (s *memoryStore) AddPostCategory(ctx context.Context, arg database.AddPostCategoryParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.data.hasPost(arg.PostID) {
+		return foreignKeyViolation("post_categories_post_id_fkey")
+	}
+	category := database.PostCategory(arg)
+	if !slices.Contains(s.data.categories, category) {
+		s.data.categories = append(s.data.categories, category)
+	}
+	return nil
+}
+
+func (s *memoryStore) DeletePostCategories(ctx context.Context, postID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.categories = slices.DeleteFunc(s.data.categories, func(c database.PostCategory) bool { return c.PostID == postID })
+	return nil
+}
+
+func (s *memoryStore) GetPostCategories(ctx context.Context, postID uuid.UUID) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for _, category := range s.data.categories {
+		if category.PostID == postID {
+			names = append(names, category.Name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (s *memoryStore) AddPostEnclosure(ctx context.Context, arg database.AddPostEnclosureParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.data.hasPost(arg.PostID) {
+		return foreignKeyViolation("post_enclosures_post_id_fkey")
+	}
+	if !slices.ContainsFunc(s.data.enclosures, func(e database.PostEnclosure) bool {
+		return e.PostID == arg.PostID && e.Url == arg.Url
+	}) {
+		s.data.enclosures = append(s.data.enclosures, database.PostEnclosure(arg))
+	}
+	return nil
+}
+
+func (s *memoryStore) DeletePostEnclosures(ctx context.Context, postID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.enclosures = slices.DeleteFunc(s.data.enclosures, func(e database.PostEnclosure) bool { return e.PostID == postID })
+	return nil
+}
+
+func (s *memoryStore) GetPostEnclosures(ctx context.Context, postID uuid.UUID) ([]database.PostEnclosure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var enclosures []database.PostEnclosure
+	for _, enclosure := range s.data.enclosures {
+		if enclosure.PostID == postID {
+			enclosures = append(enclosures, enclosure)
+		}
+	}
+	return enclosures, nil
+}
+
+func (s *memoryStore) ListPostCategories(ctx context.Context) ([]database.PostCategory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.data.categories), nil
+}
+
+func (s *memoryStore) ListPostEnclosures(ctx context.Context) ([]database.PostEnclosure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.data.enclosures), nil
+}
+
+// ===== Post state =====
+
+func (s *memoryStore) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	readAt := sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	if i := s.data.stateIndex(arg.UserID, arg.PostID); i >= 0 {
+		s.data.states[i].ReadAt = readAt
+		return nil
+	}
+	if !s.data.hasUser(arg.UserID) {
+		return foreignKeyViolation("post_states_user_id_fkey")
+	}
+	if !s.data.hasPost(arg.PostID) {
+		return foreignKeyViolation("post_states_post_id_fkey")
+	}
+	s.data.states = append(s.data.states, database.PostState{UserID: arg.UserID, PostID: arg.PostID, ReadAt: readAt})
+	return nil
+}
+
+func (s *memoryStore) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.data.stateIndex(arg.UserID, arg.PostID); i >= 0 {
+		s.data.states[i].ReadAt = sql.NullTime{}
+	}
+	return nil
+}
+
+func (s *memoryStore) MarkPostStarred(ctx context.Context, arg database.MarkPostStarredParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	starredAt := sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	if i := s.data.stateIndex(arg.UserID, arg.PostID); i >= 0 {
+		s.data.states[i].StarredAt = starredAt
+		return nil
+	}
+	if !s.data.hasUser(arg.UserID) {
+		return foreignKeyViolation("post_states_user_id_fkey")
+	}
+	if !s.data.hasPost(arg.PostID) {
+		return foreignKeyViolation("post_states_post_id_fkey")
+	}
+	s.data.states = append(s.data.states, database.PostState{UserID: arg.UserID, PostID: arg.PostID, StarredAt: starredAt})
+	return nil
+}
+
+func (s *memoryStore) MarkPostUnstarred(ctx context.Context, arg database.MarkPostUnstarredParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.data.stateIndex(arg.UserID, arg.PostID); i >= 0 {
+		s.data.states[i].StarredAt = sql.NullTime{}
+	}
+	return nil
+}
+
+func (s *memoryStore) ListPostStates(ctx context.Context) ([]database.PostState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.data.states), nil
+}
+
+func (s *memoryStore) RestorePostState(ctx context.Context, arg database.RestorePostStateParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.data.stateIndex(arg.UserID, arg.PostID); i >= 0 {
+		if !s.data.states[i].ReadAt.Valid {
+			s.data.states[i].ReadAt = arg.ReadAt
+		}
+		if !s.data.states[i].StarredAt.Valid {
+			s.data.states[i].StarredAt = arg.StarredAt
+		}
+		return nil
+	}
+	if !s.data.hasUser(arg.UserID) {
+		return foreignKeyViolation("post_states_user_id_fkey")
+	}
+	if !s.data.hasPost(arg.PostID) {
+		return foreignKeyViolation("post_states_post_id_fkey")
+	}
+	s.data.states = append(s.data.states, database.PostState(arg))
+	return nil
+}
+
+// ===== Helper Functions =====
+
+func (d *memoryData) clone() *memoryData {
+	return &memoryData{
+		users:      slices.Clone(d.users),
+		feeds:      slices.Clone(d.feeds),
+		follows:    slices.Clone(d.follows),
+		posts:      slices.Clone(d.posts),
+		states:     slices.Clone(d.states),
+		categories: slices.Clone(d.categories),
+		enclosures: slices.Clone(d.enclosures),
+	}
+}
+
+func (d *memoryData) hasUser(id uuid.UUID) bool {
+	return slices.ContainsFunc(d.users, func(u database.User) bool { return u.ID == id })
+}
+
+func (d *memoryData) hasFeed(id uuid.UUID) bool {
+	return slices.ContainsFunc(d.feeds, func(f database.Feed) bool { return f.ID == id })
+}
+
+func (d *memoryData) hasPost(id uuid.UUID) bool {
+	return slices.ContainsFunc(d.posts, func(p database.Post) bool { return p.ID == id })
+}
+
+func (d *memoryData) isFollowing(userID, feedID uuid.UUID) bool {
+	return slices.ContainsFunc(d.follows, func(ff database.FeedFollow) bool {
+		return ff.UserID == userID && ff.FeedID == feedID
+	})
+}
+
+func (d *memoryData) stateIndex(userID, postID uuid.UUID) int {
+	return slices.IndexFunc(d.states, func(ps database.PostState) bool {
+		return ps.UserID == userID && ps.PostID == postID
+	})
+}
+
+// deleteUsers removes the matching users along with everything that
+// references them, the way ON DELETE CASCADE does.
+func (d *memoryData) deleteUsers(match func(database.User) bool) {
+	deleted := map[uuid.UUID]bool{}
+	d.users = slices.DeleteFunc(d.users, func(u database.User) bool {
+		deleted[u.ID] = match(u)
+		return deleted[u.ID]
+	})
+	d.follows = slices.DeleteFunc(d.follows, func(ff database.FeedFollow) bool { return deleted[ff.UserID] })
+	d.states = slices.DeleteFunc(d.states, func(ps database.PostState) bool { return deleted[ps.UserID] })
+	d.deleteFeeds(func(f database.Feed) bool { return deleted[f.UserID] })
+}
+
+// deleteFeeds removes the matching feeds and cascades to their follows and
+// posts.
+func (d *memoryData) deleteFeeds(match func(database.Feed) bool) {
+	deleted := map[uuid.UUID]bool{}
+	d.feeds = slices.DeleteFunc(d.feeds, func(f database.Feed) bool {
+		deleted[f.ID] = match(f)
+		return deleted[f.ID]
+	})
+	d.follows = slices.DeleteFunc(d.follows, func(ff database.FeedFollow) bool { return deleted[ff.FeedID] })
+	d.deletePosts(func(p database.Post) bool { return deleted[p.FeedID] })
+}
+
+// deletePosts removes the matching posts and cascades to their read state,
+// categories and enclosures. It returns how many posts were removed.
+func (d *memoryData) deletePosts(match func(database.Post) bool) int64 {
+	deleted := map[uuid.UUID]bool{}
+	before := len(d.posts)
+	d.posts = slices.DeleteFunc(d.posts, func(p database.Post) bool {
+		deleted[p.ID] = match(p)
+		return deleted[p.ID]
+	})
+	d.states = slices.DeleteFunc(d.states, func(ps database.PostState) bool { return deleted[ps.PostID] })
+	d.categories = slices.DeleteFunc(d.categories, func(c database.PostCategory) bool { return deleted[c.PostID] })
+	d.enclosures = slices.DeleteFunc(d.enclosures, func(e database.PostEnclosure) bool { return deleted[e.PostID] })
+	return int64(before - len(d.posts))
+}
+
+// keepPost reports whether pruning must leave the post alone: someone
+// starred it, or a follower of its feed hasn't read it yet.
+func (d *memoryData) keepPost(p database.Post) bool {
+	for _, ps := range d.states {
+		if ps.PostID == p.ID && ps.StarredAt.Valid {
+			return true
+		}
+	}
+	for _, ff := range d.follows {
+		if ff.FeedID != p.FeedID {
+			continue
+		}
+		if i := d.stateIndex(ff.UserID, p.ID); i < 0 || !d.states[i].ReadAt.Valid {
+			return true
+		}
+	}
+	return false
+}
+
+// postsForUser returns the posts of the feeds userID follows, newest first,
+// optionally restricted to one feed. A negative limit means no limit.
+func (d *memoryData) postsForUser(userID, feedID uuid.UUID, limit int32) []database.GetPostsForUserRow {
+	var rows []database.GetPostsForUserRow
+	for _, post := range d.posts {
+		if feedID != uuid.Nil && post.FeedID != feedID {
+			continue
+		}
+		if !slices.ContainsFunc(d.follows, func(ff database.FeedFollow) bool {
+			return ff.UserID == userID && ff.FeedID == post.FeedID
+		}) {
+			continue
+		}
+		feed, err := findOne(d.feeds, func(f database.Feed) bool { return f.ID == post.FeedID })
+		if err != nil {
+			continue
+		}
+
+		var readAt, starredAt sql.NullTime
+		if i := d.stateIndex(userID, post.ID); i >= 0 {
+			readAt = d.states[i].ReadAt
+			starredAt = d.states[i].StarredAt
+		}
+
+		rows = append(rows, database.GetPostsForUserRow{
+			ID:              post.ID,
+			CreatedAt:       post.CreatedAt,
+			UpdatedAt:       post.UpdatedAt,
+			Title:           post.Title,
+			Url:             post.Url,
+			Description:     post.Description,
+			PublishedAt:     post.PublishedAt,
+			FeedID:          post.FeedID,
+			Content:         post.Content,
+			Guid:            post.Guid,
+			Author:          post.Author,
+			ContentEncoded:  post.ContentEncoded,
+			DurationSeconds: post.DurationSeconds,
+			Episode:         post.Episode,
+			Season:          post.Season,
+			ImageUrl:        post.ImageUrl,
+			FeedName:        feed.Name,
+			ReadAt:          readAt,
+			StarredAt:       starredAt,
+		})
+	}
+
+	// ORDER BY published_at DESC, which puts NULLs first in PostgreSQL.
+	slices.SortStableFunc(rows, func(a, b database.GetPostsForUserRow) int {
+		if a.PublishedAt.Valid != b.PublishedAt.Valid {
+			return compareNullTime(a.PublishedAt, b.PublishedAt)
+		}
+		return compareNullTime(b.PublishedAt, a.PublishedAt)
+	})
+	return limitRows(rows, limit)
+}
+
+// postTime is the time retention is measured from: when the post was
+// published, or when gator first saw it if the feed didn't say.
+func postTime(p database.Post) time.Time {
+	if p.PublishedAt.Valid {
+		return p.PublishedAt.Time
+	}
+	return p.CreatedAt
+}
+
+func findOne[T any](items []T, match func(T) bool) (T, error) {
+	if i := slices.IndexFunc(items, match); i >= 0 {
+		return items[i], nil
+	}
+	var zero T
+	return zero, sql.ErrNoRows
+}
+
+func limitRows[T any](rows []T, limit int32) []T {
+	if limit >= 0 && len(rows) > int(limit) {
+		return rows[:limit]
+	}
+	return rows
+}
+
+// compareNullTime orders NULL before any time.
+func compareNullTime(a, b sql.NullTime) int {
+	switch {
+	case !a.Valid && !b.Valid:
+		return 0
+	case !a.Valid:
+		return -1
+	case !b.Valid:
+		return 1
+	}
+	return a.Time.Compare(b.Time)
+}
+
+func uniqueViolation(constraint string) error {
+	return fmt.Errorf("duplicate key value violates unique constraint %q", constraint)
+}
+
+func foreignKeyViolation(constraint string) error {
+	return fmt.Errorf("insert or update violates foreign key constraint %q", constraint)
+}
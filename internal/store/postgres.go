@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/adamararcane/gator/internal/database"
+	_ "github.com/lib/pq"
+)
+
+// postgresStore is the sqlc generated queries as they are.
+type postgresStore struct {
+	*database.Queries
+	db *sql.DB
+}
+
+var _ Store = (*postgresStore)(nil)
+
+func newPostgresStore(db *sql.DB) *postgresStore {
+	return &postgresStore{Queries: database.New(db), db: db}
+}
+
+func (s *postgresStore) InTx(ctx context.Context, fn func(Store) error) error {
+	return inTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		return fn(&postgresStore{Queries: s.Queries.WithTx(tx), db: s.db})
+	})
+}
+
+func (s *postgresStore) InReadTx(ctx context.Context, fn func(Store) error) error {
+	return inTx(ctx, s.db, readTxOptions, func(tx *sql.Tx) error {
+		return fn(&postgresStore{Queries: s.Queries.WithTx(tx), db: s.db})
+	})
+}
@@ -0,0 +1,426 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/adamararcane/gator/internal/database/sqlite"
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteStore adapts the queries generated from sql/sqlite to the Store
+// interface. The generated types mirror the PostgreSQL ones field for field,
+// so most methods are plain conversions.
+//
+// SQLite stores timestamps as text and compares them as strings, so every
+// time gator writes is normalized to UTC to keep ordering correct.
+type sqliteStore struct {
+	q  *sqlite.Queries
+	db *sql.DB
+}
+
+var _ Store = (*sqliteStore)(nil)
+
+func newSQLiteStore(db *sql.DB) *sqliteStore {
+	return &sqliteStore{q: sqlite.New(db), db: db}
+}
+
+func (s *sqliteStore) InTx(ctx context.Context, fn func(Store) error) error {
+	return inTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		return fn(&sqliteStore{q: s.q.WithTx(tx), db: s.db})
+	})
+}
+
+func (s *sqliteStore) InReadTx(ctx context.Context, fn func(Store) error) error {
+	return inTx(ctx, s.db, readTxOptions, func(tx *sql.Tx) error {
+		return fn(&sqliteStore{q: s.q.WithTx(tx), db: s.db})
+	})
+}
+
+// ===== Users =====
+
+func (s *sqliteStore) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	arg.CreatedAt = arg.CreatedAt.UTC()
+	arg.UpdatedAt = arg.UpdatedAt.UTC()
+	user, err := s.q.CreateUser(ctx, sqlite.CreateUserParams(arg))
+	return database.User(user), err
+}
+
+func (s *sqliteStore) GetUser(ctx context.Context, name string) (database.User, error) {
+	user, err := s.q.GetUser(ctx, name)
+	return database.User(user), err
+}
+
+func (s *sqliteStore) GetUserName(ctx context.Context, id uuid.UUID) (string, error) {
+	return s.q.GetUserName(ctx, id)
+}
+
+func (s *sqliteStore) GetUsers(ctx context.Context) ([]string, error) {
+	return s.q.GetUsers(ctx)
+}
+
+func (s *sqliteStore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return s.q.DeleteUser(ctx, id)
+}
+
+func (s *sqliteStore) CountAdmins(ctx context.Context) (int64, error) {
+	return s.q.CountAdmins(ctx)
+}
+
+func (s *sqliteStore) PromoteUser(ctx context.Context, id uuid.UUID) error {
+	return s.q.PromoteUser(ctx, id)
+}
+
+func (s *sqliteStore) ListUsers(ctx context.Context) ([]database.User, error) {
+	users, err := s.q.ListUsers(ctx)
+	return convertAll(users, func(u sqlite.User) database.User { return database.User(u) }), err
+}
+
+func (s *sqliteStore) RestoreUser(ctx context.Context, arg database.RestoreUserParams) (int64, error) {
+	arg.CreatedAt = arg.CreatedAt.UTC()
+	arg.UpdatedAt = arg.UpdatedAt.UTC()
+	return s.q.RestoreUser(ctx, sqlite.RestoreUserParams(arg))
+}
+
+func (s *sqliteStore) ResetDatabase(ctx context.Context) error {
+	return s.q.ResetDatabase(ctx)
+}
+
+// ===== Feeds =====
+
+func (s *sqliteStore) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	arg.CreatedAt = arg.CreatedAt.UTC()
+	arg.UpdatedAt = arg.UpdatedAt.UTC()
+	feed, err := s.q.CreateFeed(ctx, sqlite.CreateFeedParams(arg))
+	return database.Feed(feed), err
+}
+
+func (s *sqliteStore) GetFeed(ctx context.Context, url string) (database.Feed, error) {
+	feed, err := s.q.GetFeed(ctx, url)
+	return database.Feed(feed), err
+}
+
+func (s *sqliteStore) GetFeedByID(ctx context.Context, id uuid.UUID) (database.Feed, error) {
+	feed, err := s.q.GetFeedByID(ctx, id)
+	return database.Feed(feed), err
+}
+
+func (s *sqliteStore) GetFeeds(ctx context.Context) ([]database.GetFeedsRow, error) {
+	rows, err := s.q.GetFeeds(ctx)
+	return convertAll(rows, func(r sqlite.GetFeedsRow) database.GetFeedsRow { return database.GetFeedsRow(r) }), err
+}
+
+func (s *sqliteStore) GetNextFeedToFetch(ctx context.Context) (database.GetNextFeedToFetchRow, error) {
+	row, err := s.q.GetNextFeedToFetch(ctx)
+	return database.GetNextFeedToFetchRow{Feed: database.Feed(row.Feed), DueSeconds: row.DueSeconds}, err
+}
+
+func (s *sqliteStore) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error {
+	return s.q.MarkFeedFetched(ctx, sqlite.MarkFeedFetchedParams{
+		IntervalSeconds: int64(arg.IntervalSeconds),
+		ID:              arg.ID,
+	})
+}
+
+func (s *sqliteStore) DeferFeedFetch(ctx context.Context, arg database.DeferFeedFetchParams) error {
+	return s.q.DeferFeedFetch(ctx, sqlite.DeferFeedFetchParams{
+		DelaySeconds: int64(arg.DelaySeconds),
+		ID:           arg.ID,
+	})
+}
+
+func (s *sqliteStore) SetFeedFetchInterval(ctx context.Context, arg database.SetFeedFetchIntervalParams) error {
+	return s.q.SetFeedFetchInterval(ctx, sqlite.SetFeedFetchIntervalParams{
+		FetchIntervalSeconds: arg.FetchIntervalSeconds,
+		ID:                   arg.ID,
+	})
+}
+
+func (s *sqliteStore) SetFeedUrl(ctx context.Context, arg database.SetFeedUrlParams) error {
+	return s.q.SetFeedUrl(ctx, sqlite.SetFeedUrlParams{
+		Url: arg.Url,
+		ID:  arg.ID,
+	})
+}
+
+func (s *sqliteStore) MarkFeedDead(ctx context.Context, id uuid.UUID) error {
+	return s.q.MarkFeedDead(ctx, id)
+}
+
+func (s *sqliteStore) ReviveFeed(ctx context.Context, id uuid.UUID) error {
+	return s.q.ReviveFeed(ctx, id)
+}
+
+func (s *sqliteStore) SetFeedParseWarning(ctx context.Context, arg database.SetFeedParseWarningParams) error {
+	return s.q.SetFeedParseWarning(ctx, sqlite.SetFeedParseWarningParams{
+		ParseWarning: arg.ParseWarning,
+		ID:           arg.ID,
+	})
+}
+
+func (s *sqliteStore) SetFeedAuth(ctx context.Context, arg database.SetFeedAuthParams) error {
+	return s.q.SetFeedAuth(ctx, sqlite.SetFeedAuthParams{
+		Auth: arg.Auth,
+		ID:   arg.ID,
+	})
+}
+
+func (s *sqliteStore) MoveFeedFollows(ctx context.Context, arg database.MoveFeedFollowsParams) error {
+	return s.q.MoveFeedFollows(ctx, sqlite.MoveFeedFollowsParams(arg))
+}
+
+func (s *sqliteStore) MoveFeedPostStates(ctx context.Context, arg database.MoveFeedPostStatesParams) error {
+	return s.q.MoveFeedPostStates(ctx, sqlite.MoveFeedPostStatesParams(arg))
+}
+
+func (s *sqliteStore) MoveFeedPosts(ctx context.Context, arg database.MoveFeedPostsParams) error {
+	return s.q.MoveFeedPosts(ctx, sqlite.MoveFeedPostsParams(arg))
+}
+
+func (s *sqliteStore) GetRecentPostTimes(ctx context.Context, arg database.GetRecentPostTimesParams) ([]sql.NullTime, error) {
+	return s.q.GetRecentPostTimes(ctx, sqlite.GetRecentPostTimesParams{
+		FeedID: arg.FeedID,
+		Limit:  int64(arg.Limit),
+	})
+}
+
+func (s *sqliteStore) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	return s.q.DeleteFeed(ctx, id)
+}
+
+func (s *sqliteStore) CountFeedsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.q.CountFeedsForUser(ctx, userID)
+}
+
+func (s *sqliteStore) DeleteAllFeeds(ctx context.Context) error {
+	return s.q.DeleteAllFeeds(ctx)
+}
+
+func (s *sqliteStore) ListFeeds(ctx context.Context) ([]database.Feed, error) {
+	feeds, err := s.q.ListFeeds(ctx)
+	return convertAll(feeds, func(f sqlite.Feed) database.Feed { return database.Feed(f) }), err
+}
+
+func (s *sqliteStore) RestoreFeed(ctx context.Context, arg database.RestoreFeedParams) (int64, error) {
+	arg.CreatedAt = arg.CreatedAt.UTC()
+	arg.UpdatedAt = arg.UpdatedAt.UTC()
+	arg.LastFetchedAt = utcNullTime(arg.LastFetchedAt)
+	arg.NextFetchAt = utcNullTime(arg.NextFetchAt)
+	arg.DeadAt = utcNullTime(arg.DeadAt)
+	return s.q.RestoreFeed(ctx, sqlite.RestoreFeedParams(arg))
+}
+
+// ===== Feed follows =====
+
+// CreateFeedFollow inserts the follow and reads it back with the user and
+// feed names, since SQLite can't run an INSERT inside a CTE.
+func (s *sqliteStore) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) ([]database.CreateFeedFollowRow, error) {
+	if err := s.q.CreateFeedFollow(ctx, sqlite.CreateFeedFollowParams(arg)); err != nil {
+		return nil, err
+	}
+	row, err := s.q.GetFeedFollow(ctx, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	return []database.CreateFeedFollowRow{database.CreateFeedFollowRow(row)}, nil
+}
+
+func (s *sqliteStore) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	rows, err := s.q.GetFeedFollowsForUser(ctx, userID)
+	return convertAll(rows, func(r sqlite.GetFeedFollowsForUserRow) database.GetFeedFollowsForUserRow {
+		return database.GetFeedFollowsForUserRow(r)
+	}), err
+}
+
+func (s *sqliteStore) UnfollowFeed(ctx context.Context, arg database.UnfollowFeedParams) error {
+	return s.q.UnfollowFeed(ctx, sqlite.UnfollowFeedParams(arg))
+}
+
+func (s *sqliteStore) ListFeedFollows(ctx context.Context) ([]database.FeedFollow, error) {
+	follows, err := s.q.ListFeedFollows(ctx)
+	return convertAll(follows, func(ff sqlite.FeedFollow) database.FeedFollow { return database.FeedFollow(ff) }), err
+}
+
+func (s *sqliteStore) RestoreFeedFollow(ctx context.Context, arg database.RestoreFeedFollowParams) error {
+	arg.CreatedAt = arg.CreatedAt.UTC()
+	arg.UpdatedAt = arg.UpdatedAt.UTC()
+	return s.q.RestoreFeedFollow(ctx, sqlite.RestoreFeedFollowParams(arg))
+}
+
+// ===== Posts =====
+
+func (s *sqliteStore) RekeyLegacyPost(ctx context.Context, arg database.RekeyLegacyPostParams) error {
+	return s.q.RekeyLegacyPost(ctx, sqlite.RekeyLegacyPostParams(arg))
+}
+
+func (s *sqliteStore) UpsertPost(ctx context.Context, arg database.UpsertPostParams) (database.Post, error) {
+	arg.PublishedAt = utcNullTime(arg.PublishedAt)
+	post, err := s.q.UpsertPost(ctx, sqlite.UpsertPostParams(arg))
+	return database.Post(post), err
+}
+
+func (s *sqliteStore) UpdatePostContent(ctx context.Context, arg database.UpdatePostContentParams) error {
+	return s.q.UpdatePostContent(ctx, sqlite.UpdatePostContentParams{
+		Content: arg.Content,
+		ID:      arg.ID,
+	})
+}
+
+func (s *sqliteStore) GetPostsByIDPrefix(ctx context.Context, arg database.GetPostsByIDPrefixParams) ([]database.Post, error) {
+	posts, err := s.q.GetPostsByIDPrefix(ctx, sqlite.GetPostsByIDPrefixParams(arg))
+	return convertAll(posts, func(p sqlite.Post) database.Post { return database.Post(p) }), err
+}
+
+func (s *sqliteStore) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	rows, err := s.q.GetPostsForUser(ctx, sqlite.GetPostsForUserParams{
+		UserID: arg.UserID,
+		Limit:  int64(arg.Limit),
+	})
+	return convertAll(rows, func(r sqlite.GetPostsForUserRow) database.GetPostsForUserRow {
+		return database.GetPostsForUserRow(r)
+	}), err
+}
+
+func (s *sqliteStore) GetPostsForUserFeed(ctx context.Context, arg database.GetPostsForUserFeedParams) ([]database.GetPostsForUserFeedRow, error) {
+	rows, err := s.q.GetPostsForUserFeed(ctx, sqlite.GetPostsForUserFeedParams{
+		UserID: arg.UserID,
+		FeedID: arg.FeedID,
+		Limit:  int64(arg.Limit),
+	})
+	return convertAll(rows, func(r sqlite.GetPostsForUserFeedRow) database.GetPostsForUserFeedRow {
+		return database.GetPostsForUserFeedRow(r)
+	}), err
+}
+
+func (s *sqliteStore) GetEpisodesForUser(ctx context.Context, arg database.GetEpisodesForUserParams) ([]database.GetEpisodesForUserRow, error) {
+	rows, err := s.q.GetEpisodesForUser(ctx, sqlite.GetEpisodesForUserParams{
+		UserID: arg.UserID,
+		Limit:  int64(arg.Limit),
+	})
+	return convertAll(rows, func(r sqlite.GetEpisodesForUserRow) database.GetEpisodesForUserRow {
+		return database.GetEpisodesForUserRow(r)
+	}), err
+}
+
+func (s *sqliteStore) DeleteAllPosts(ctx context.Context) error {
+	return s.q.DeleteAllPosts(ctx)
+}
+
+func (s *sqliteStore) ListPosts(ctx context.Context) ([]database.Post, error) {
+	posts, err := s.q.ListPosts(ctx)
+	return convertAll(posts, func(p sqlite.Post) database.Post { return database.Post(p) }), err
+}
+
+func (s *sqliteStore) GetPostByGuid(ctx context.Context, arg database.GetPostByGuidParams) (database.Post, error) {
+	post, err := s.q.GetPostByGuid(ctx, sqlite.GetPostByGuidParams(arg))
+	return database.Post(post), err
+}
+
+func (s *sqliteStore) RestorePost(ctx context.Context, arg database.RestorePostParams) (int64, error) {
+	arg.CreatedAt = arg.CreatedAt.UTC()
+	arg.UpdatedAt = arg.UpdatedAt.UTC()
+	arg.PublishedAt = utcNullTime(arg.PublishedAt)
+	return s.q.RestorePost(ctx, sqlite.RestorePostParams(arg))
+}
+
+func (s *sqliteStore) PrunePostsOlderThan(ctx context.Context, arg database.PrunePostsOlderThanParams) (int64, error) {
+	return s.q.PrunePostsOlderThan(ctx, sqlite.PrunePostsOlderThanParams{
+		FeedID: arg.FeedID,
+		Cutoff: sql.NullTime{Time: arg.Cutoff.UTC(), Valid: true},
+	})
+}
+
+func (s *sqliteStore) PrunePostsBeyondNewest(ctx context.Context, arg database.PrunePostsBeyondNewestParams) (int64, error) {
+	return s.q.PrunePostsBeyondNewest(ctx, sqlite.PrunePostsBeyondNewestParams{
+		FeedID: arg.FeedID,
+		Keep:   int64(arg.Keep),
+	})
+}
+
+// ===== Post metadata =====
+
+func (s *sqliteStore) AddPostCategory(ctx context.Context, arg database.AddPostCategoryParams) error {
+	return s.q.AddPostCategory(ctx, sqlite.AddPostCategoryParams(arg))
+}
+
+func (s *sqliteStore) DeletePostCategories(ctx context.Context, postID uuid.UUID) error {
+	return s.q.DeletePostCategories(ctx, postID)
+}
+
+func (s *sqliteStore) GetPostCategories(ctx context.Context, postID uuid.UUID) ([]string, error) {
+	return s.q.GetPostCategories(ctx, postID)
+}
+
+func (s *sqliteStore) AddPostEnclosure(ctx context.Context, arg database.AddPostEnclosureParams) error {
+	return s.q.AddPostEnclosure(ctx, sqlite.AddPostEnclosureParams(arg))
+}
+
+func (s *sqliteStore) DeletePostEnclosures(ctx context.Context, postID uuid.UUID) error {
+	return s.q.DeletePostEnclosures(ctx, postID)
+}
+
+func (s *sqliteStore) GetPostEnclosures(ctx context.Context, postID uuid.UUID) ([]database.PostEnclosure, error) {
+	rows, err := s.q.GetPostEnclosures(ctx, postID)
+	return convertAll(rows, func(r sqlite.PostEnclosure) database.PostEnclosure { return database.PostEnclosure(r) }), err
+}
+
+func (s *sqliteStore) ListPostCategories(ctx context.Context) ([]database.PostCategory, error) {
+	rows, err := s.q.ListPostCategories(ctx)
+	return convertAll(rows, func(r sqlite.PostCategory) database.PostCategory { return database.PostCategory(r) }), err
+}
+
+func (s *sqliteStore) ListPostEnclosures(ctx context.Context) ([]database.PostEnclosure, error) {
+	rows, err := s.q.ListPostEnclosures(ctx)
+	return convertAll(rows, func(r sqlite.PostEnclosure) database.PostEnclosure { return database.PostEnclosure(r) }), err
+}
+
+// ===== Post state =====
+
+func (s *sqliteStore) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	return s.q.MarkPostRead(ctx, sqlite.MarkPostReadParams(arg))
+}
+
+func (s *sqliteStore) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) error {
+	return s.q.MarkPostUnread(ctx, sqlite.MarkPostUnreadParams(arg))
+}
+
+func (s *sqliteStore) MarkPostStarred(ctx context.Context, arg database.MarkPostStarredParams) error {
+	return s.q.MarkPostStarred(ctx, sqlite.MarkPostStarredParams(arg))
+}
+
+func (s *sqliteStore) MarkPostUnstarred(ctx context.Context, arg database.MarkPostUnstarredParams) error {
+	return s.q.MarkPostUnstarred(ctx, sqlite.MarkPostUnstarredParams(arg))
+}
+
+func (s *sqliteStore) ListPostStates(ctx context.Context) ([]database.PostState, error) {
+	rows, err := s.q.ListPostStates(ctx)
+	return convertAll(rows, func(r sqlite.PostState) database.PostState { return database.PostState(r) }), err
+}
+
+func (s *sqliteStore) RestorePostState(ctx context.Context, arg database.RestorePostStateParams) error {
+	arg.ReadAt = utcNullTime(arg.ReadAt)
+	arg.StarredAt = utcNullTime(arg.StarredAt)
+	return s.q.RestorePostState(ctx, sqlite.RestorePostStateParams(arg))
+}
+
+// ===== Helper Functions =====
+
+func convertAll[S, D any](items []S, convert func(S) D) []D {
+	if items == nil {
+		return nil
+	}
+	out := make([]D, len(items))
+	for i, item := range items {
+		out[i] = convert(item)
+	}
+	return out
+}
+
+func utcNullTime(t sql.NullTime) sql.NullTime {
+	if t.Valid {
+		t.Time = t.Time.UTC()
+	}
+	return t
+}
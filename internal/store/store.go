@@ -0,0 +1,183 @@
+// Package store abstracts the database gator keeps its data in, so the same
+// commands run against PostgreSQL or SQLite.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// Store is the set of queries gator runs. Parameter and result types are
+// the ones sqlc generates for PostgreSQL; other backends convert to them.
+type Store interface {
+	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+	GetUser(ctx context.Context, name string) (database.User, error)
+	GetUserName(ctx context.Context, id uuid.UUID) (string, error)
+	GetUsers(ctx context.Context) ([]string, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	CountAdmins(ctx context.Context) (int64, error)
+	PromoteUser(ctx context.Context, id uuid.UUID) error
+	ListUsers(ctx context.Context) ([]database.User, error)
+	RestoreUser(ctx context.Context, arg database.RestoreUserParams) (int64, error)
+	ResetDatabase(ctx context.Context) error
+
+	CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error)
+	GetFeed(ctx context.Context, url string) (database.Feed, error)
+	GetFeedByID(ctx context.Context, id uuid.UUID) (database.Feed, error)
+	GetFeeds(ctx context.Context) ([]database.GetFeedsRow, error)
+	GetNextFeedToFetch(ctx context.Context) (database.GetNextFeedToFetchRow, error)
+	MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error
+	DeferFeedFetch(ctx context.Context, arg database.DeferFeedFetchParams) error
+	SetFeedFetchInterval(ctx context.Context, arg database.SetFeedFetchIntervalParams) error
+	SetFeedUrl(ctx context.Context, arg database.SetFeedUrlParams) error
+	MarkFeedDead(ctx context.Context, id uuid.UUID) error
+	ReviveFeed(ctx context.Context, id uuid.UUID) error
+	SetFeedParseWarning(ctx context.Context, arg database.SetFeedParseWarningParams) error
+	SetFeedAuth(ctx context.Context, arg database.SetFeedAuthParams) error
+	MoveFeedFollows(ctx context.Context, arg database.MoveFeedFollowsParams) error
+	MoveFeedPostStates(ctx context.Context, arg database.MoveFeedPostStatesParams) error
+	MoveFeedPosts(ctx context.Context, arg database.MoveFeedPostsParams) error
+	GetRecentPostTimes(ctx context.Context, arg database.GetRecentPostTimesParams) ([]sql.NullTime, error)
+	DeleteFeed(ctx context.Context, id uuid.UUID) error
+	CountFeedsForUser(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteAllFeeds(ctx context.Context) error
+	ListFeeds(ctx context.Context) ([]database.Feed, error)
+	RestoreFeed(ctx context.Context, arg database.RestoreFeedParams) (int64, error)
+
+	CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) ([]database.CreateFeedFollowRow, error)
+	GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error)
+	UnfollowFeed(ctx context.Context, arg database.UnfollowFeedParams) error
+	ListFeedFollows(ctx context.Context) ([]database.FeedFollow, error)
+	RestoreFeedFollow(ctx context.Context, arg database.RestoreFeedFollowParams) error
+
+	RekeyLegacyPost(ctx context.Context, arg database.RekeyLegacyPostParams) error
+	UpsertPost(ctx context.Context, arg database.UpsertPostParams) (database.Post, error)
+	UpdatePostContent(ctx context.Context, arg database.UpdatePostContentParams) error
+	GetPostsByIDPrefix(ctx context.Context, arg database.GetPostsByIDPrefixParams) ([]database.Post, error)
+	GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error)
+	GetPostsForUserFeed(ctx context.Context, arg database.GetPostsForUserFeedParams) ([]database.GetPostsForUserFeedRow, error)
+	GetEpisodesForUser(ctx context.Context, arg database.GetEpisodesForUserParams) ([]database.GetEpisodesForUserRow, error)
+	DeleteAllPosts(ctx context.Context) error
+	ListPosts(ctx context.Context) ([]database.Post, error)
+	GetPostByGuid(ctx context.Context, arg database.GetPostByGuidParams) (database.Post, error)
+	RestorePost(ctx context.Context, arg database.RestorePostParams) (int64, error)
+	PrunePostsOlderThan(ctx context.Context, arg database.PrunePostsOlderThanParams) (int64, error)
+	PrunePostsBeyondNewest(ctx context.Context, arg database.PrunePostsBeyondNewestParams) (int64, error)
+
+	AddPostCategory(ctx context.Context, arg database.AddPostCategoryParams) error
+	DeletePostCategories(ctx context.Context, postID uuid.UUID) error
+	GetPostCategories(ctx context.Context, postID uuid.UUID) ([]string, error)
+	AddPostEnclosure(ctx context.Context, arg database.AddPostEnclosureParams) error
+	DeletePostEnclosures(ctx context.Context, postID uuid.UUID) error
+	GetPostEnclosures(ctx context.Context, postID uuid.UUID) ([]database.PostEnclosure, error)
+	ListPostCategories(ctx context.Context) ([]database.PostCategory, error)
+	ListPostEnclosures(ctx context.Context) ([]database.PostEnclosure, error)
+
+	MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error
+	MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) error
+	MarkPostStarred(ctx context.Context, arg database.MarkPostStarredParams) error
+	MarkPostUnstarred(ctx context.Context, arg database.MarkPostUnstarredParams) error
+	ListPostStates(ctx context.Context) ([]database.PostState, error)
+	RestorePostState(ctx context.Context, arg database.RestorePostStateParams) error
+
+	// InTx runs fn with a Store bound to a single transaction, committing
+	// only if fn succeeds.
+	InTx(ctx context.Context, fn func(Store) error) error
+	// InReadTx runs fn with a Store bound to a read-only transaction that
+	// sees every table as of the same moment, for reads that must agree
+	// with each other.
+	InReadTx(ctx context.Context, fn func(Store) error) error
+}
+
+type Backend string
+
+const (
+	Postgres Backend = "postgres"
+	SQLite   Backend = "sqlite"
+)
+
+// DriverName is the database/sql driver registered for the backend.
+func (b Backend) DriverName() string {
+	return string(b)
+}
+
+// ParseURL picks the backend from the scheme of dbURL and returns the data
+// source name to open it with. sqlite:<path>, sqlite://<path> and
+// file:<path> go to SQLite; anything else, postgres:// URLs and libpq
+// key=value strings alike, is handed to PostgreSQL as is.
+func ParseURL(dbURL string) (Backend, string, error) {
+	switch {
+	case strings.HasPrefix(dbURL, "sqlite:"):
+		path := strings.TrimPrefix(strings.TrimPrefix(dbURL, "sqlite://"), "sqlite:")
+		dsn, err := sqliteDSN(path)
+		return SQLite, dsn, err
+	case strings.HasPrefix(dbURL, "file:"):
+		dsn, err := sqliteDSN(dbURL)
+		return SQLite, dsn, err
+	}
+	return Postgres, dbURL, nil
+}
+
+// sqliteDSN expands a leading ~ and turns on the pragmas gator relies on:
+// foreign keys for cascading deletes, and a busy timeout and WAL journal so
+// agg can write while other commands read.
+func sqliteDSN(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("sqlite db_url needs a file path")
+	}
+
+	if strings.HasPrefix(path, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error finding user home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, path[2:])
+	}
+
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + sqlitePragmas, nil
+}
+
+const sqlitePragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+
+// New returns the Store for backend on top of an open connection pool.
+func New(backend Backend, db *sql.DB) Store {
+	if backend == SQLite {
+		return newSQLiteStore(db)
+	}
+	return newPostgresStore(db)
+}
+
+// readTxOptions give a read-only transaction a single snapshot. Postgres
+// defaults to READ COMMITTED, where each statement sees the latest commits;
+// SQLite transactions are always serializable.
+var readTxOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+// inTx begins a transaction on db with opts, hands it to fn and commits if
+// fn succeeds.
+func inTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,587 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+)
+
+// postgresURLEnv names the variable holding a PostgreSQL database the
+// conformance tests may wipe. Without it only SQLite and memory are tested.
+const postgresURLEnv = "GATOR_TEST_POSTGRES_URL"
+
+// backends returns a constructor for an empty Store of every backend that
+// can run here.
+func backends(t *testing.T) map[string]func(t *testing.T) Store {
+	t.Helper()
+	backends := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemory() },
+		"sqlite": func(t *testing.T) Store {
+			dsn, err := sqliteDSN(filepath.Join(t.TempDir(), "gator.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return openMigrated(t, SQLite, dsn, goose.DialectSQLite3, "../../sql/sqlite/schema")
+		},
+	}
+	if dsn := os.Getenv(postgresURLEnv); dsn != "" {
+		backends["postgres"] = func(t *testing.T) Store {
+			s := openMigrated(t, Postgres, dsn, goose.DialectPostgres, "../../sql/schema")
+			if err := s.ResetDatabase(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			return s
+		}
+	}
+	return backends
+}
+
+func openMigrated(t *testing.T, backend Backend, dsn string, dialect goose.Dialect, dir string) Store {
+	t.Helper()
+	db, err := sql.Open(backend.DriverName(), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	provider, err := goose.NewProvider(dialect, db, os.DirFS(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return New(backend, db)
+}
+
+// TestStoreConformance runs the same scenarios against every backend, so
+// they can't drift apart in behaviour.
+func TestStoreConformance(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s Store)
+	}{
+		{"first user is admin", testFirstUserIsAdmin},
+		{"missing user", testMissingUser},
+		{"duplicate user name", testDuplicateUserName},
+		{"promote user", testPromoteUser},
+		{"follow and unfollow", testFollowAndUnfollow},
+		{"upsert post", testUpsertPost},
+		{"post states", testPostStates},
+		{"short ids are scoped to followers", testPostsByIDPrefix},
+		{"episodes list each post once", testEpisodesOncePerPost},
+		{"merge feeds keeps marks", testMergeFeedsKeepsMarks},
+		{"rekey legacy post", testRekeyLegacyPost},
+		{"restore reports inserted rows", testRestoreRowsAffected},
+		{"delete user cascades", testDeleteUserCascades},
+		{"next feed reports how long it has been due", testNextFeedDueSeconds},
+		{"rolled back transaction", testRollback},
+		{"read transaction sees one snapshot", testReadTxSnapshot},
+	}
+
+	for backend, open := range backends(t) {
+		for _, tt := range tests {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				tt.run(t, open(t))
+			})
+		}
+	}
+}
+
+func testFirstUserIsAdmin(t *testing.T, s Store) {
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	if !alice.IsAdmin || bob.IsAdmin {
+		t.Errorf("admins: alice %v, bob %v; want only alice", alice.IsAdmin, bob.IsAdmin)
+	}
+
+	got, err := s.GetUser(context.Background(), "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != bob.ID {
+		t.Errorf("GetUser(bob) = %v, want %v", got.ID, bob.ID)
+	}
+}
+
+func testMissingUser(t *testing.T, s Store) {
+	_, err := s.GetUser(context.Background(), "nobody")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUser(nobody) error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testDuplicateUserName(t *testing.T, s Store) {
+	createUser(t, s, "alice")
+	_, err := s.CreateUser(context.Background(), database.CreateUserParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      "alice",
+	})
+	if err == nil {
+		t.Error("creating a second alice succeeded, want an error")
+	}
+}
+
+func testPromoteUser(t *testing.T, s Store) {
+	ctx := context.Background()
+	createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	if err := s.PromoteUser(ctx, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	admins, err := s.CountAdmins(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if admins != 2 {
+		t.Errorf("CountAdmins() = %d, want 2", admins)
+	}
+}
+
+func testFollowAndUnfollow(t *testing.T, s Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "Blog", "https://example.com/feed")
+
+	rows, err := s.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), UserID: alice.ID, FeedID: feed.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].UserName != "alice" || rows[0].FeedName != "Blog" {
+		t.Errorf("CreateFeedFollow() = %+v, want alice following Blog", rows)
+	}
+
+	follows, err := s.GetFeedFollowsForUser(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(follows) != 1 || follows[0].ID != feed.ID {
+		t.Errorf("GetFeedFollowsForUser() = %+v, want Blog", follows)
+	}
+
+	if err := s.UnfollowFeed(ctx, database.UnfollowFeedParams{UserID: alice.ID, FeedID: feed.ID}); err != nil {
+		t.Fatal(err)
+	}
+	follows, err = s.GetFeedFollowsForUser(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(follows) != 0 {
+		t.Errorf("GetFeedFollowsForUser() after unfollow = %+v, want none", follows)
+	}
+}
+
+func testUpsertPost(t *testing.T, s Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "Blog", "https://example.com/feed")
+	follow(t, s, alice, feed)
+
+	params := postParams(feed, "g1", "First")
+	first, err := s.UpsertPost(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params.ID = uuid.New()
+	if _, err := s.UpsertPost(ctx, params); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("upserting an unchanged post: error = %v, want sql.ErrNoRows", err)
+	}
+
+	params.Title = "First, edited"
+	edited, err := s.UpsertPost(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.ID != first.ID || edited.Title != "First, edited" {
+		t.Errorf("upserting an edited post = %v %q, want %v %q", edited.ID, edited.Title, first.ID, "First, edited")
+	}
+
+	posts, err := s.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].FeedName != "Blog" {
+		t.Errorf("GetPostsForUser() = %+v, want the one post from Blog", posts)
+	}
+}
+
+func testPostStates(t *testing.T, s Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "Blog", "https://example.com/feed")
+	follow(t, s, alice, feed)
+	post := createPost(t, s, feed, "g1", "First")
+
+	if err := s.MarkPostRead(ctx, database.MarkPostReadParams{UserID: alice.ID, PostID: post.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkPostStarred(ctx, database.MarkPostStarredParams{UserID: alice.ID, PostID: post.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	posts, err := s.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || !posts[0].ReadAt.Valid || !posts[0].StarredAt.Valid {
+		t.Errorf("GetPostsForUser() = %+v, want the post read and starred", posts)
+	}
+}
+
+func testPostsByIDPrefix(t *testing.T, s Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	feed := createFeed(t, s, alice, "Blog", "https://example.com/feed")
+	follow(t, s, alice, feed)
+	post := createPost(t, s, feed, "g1", "First")
+	prefix := post.ID.String()[:8]
+
+	posts, err := s.GetPostsByIDPrefix(ctx, database.GetPostsByIDPrefixParams{UserID: alice.ID, Prefix: prefix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].ID != post.ID {
+		t.Errorf("GetPostsByIDPrefix(alice) = %+v, want the post", posts)
+	}
+
+	posts, err = s.GetPostsByIDPrefix(ctx, database.GetPostsByIDPrefixParams{UserID: bob.ID, Prefix: prefix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 0 {
+		t.Errorf("GetPostsByIDPrefix(bob) = %+v, want none for a feed bob doesn't follow", posts)
+	}
+}
+
+func testEpisodesOncePerPost(t *testing.T, s Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "Podcast", "https://example.com/podcast")
+	follow(t, s, alice, feed)
+	post := createPost(t, s, feed, "g1", "Episode")
+	for _, url := range []string{"https://example.com/b.mp3", "https://example.com/a.mp3"} {
+		err := s.AddPostEnclosure(ctx, database.AddPostEnclosureParams{PostID: post.ID, Url: url, Type: "audio/mpeg"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	episodes, err := s.GetEpisodesForUser(ctx, database.GetEpisodesForUserParams{UserID: alice.ID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(episodes) != 1 || episodes[0].EnclosureUrl != "https://example.com/a.mp3" {
+		t.Errorf("GetEpisodesForUser() = %+v, want the post once with a.mp3", episodes)
+	}
+}
+
+func testMergeFeedsKeepsMarks(t *testing.T, s Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	from := createFeed(t, s, alice, "Old", "http://example.com/feed")
+	to := createFeed(t, s, alice, "New", "https://example.com/feed")
+	follow(t, s, alice, from)
+	shared := createPost(t, s, from, "shared", "Shared")
+	createPost(t, s, from, "only-old", "Only old")
+	target := createPost(t, s, to, "shared", "Shared")
+	if err := s.MarkPostStarred(ctx, database.MarkPostStarredParams{UserID: alice.ID, PostID: shared.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.InTx(ctx, func(q Store) error {
+		if err := q.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{FromFeedID: from.ID, ToFeedID: to.ID}); err != nil {
+			return err
+		}
+		if err := q.MoveFeedPostStates(ctx, database.MoveFeedPostStatesParams{FromFeedID: from.ID, ToFeedID: to.ID}); err != nil {
+			return err
+		}
+		if err := q.MoveFeedPosts(ctx, database.MoveFeedPostsParams{FromFeedID: from.ID, ToFeedID: to.ID}); err != nil {
+			return err
+		}
+		return q.DeleteFeed(ctx, from.ID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	posts, err := s.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: alice.ID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("GetPostsForUser() after merge = %d posts, want 2", len(posts))
+	}
+	for _, post := range posts {
+		if post.FeedID != to.ID {
+			t.Errorf("post %q is on feed %v, want %v", post.Title, post.FeedID, to.ID)
+		}
+		if post.ID == target.ID && !post.StarredAt.Valid {
+			t.Error("the star on the merged post was lost")
+		}
+	}
+}
+
+func testRekeyLegacyPost(t *testing.T, s Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "Blog", "https://example.com/feed")
+	legacy := postParams(feed, "https://example.com/1", "Legacy")
+	legacy.Url = "https://example.com/1"
+	post, err := s.UpsertPost(ctx, legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.RekeyLegacyPost(ctx, database.RekeyLegacyPostParams{Guid: "tag:1", FeedID: feed.ID, Url: legacy.Url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekeyed, err := s.GetPostByGuid(ctx, database.GetPostByGuidParams{FeedID: feed.ID, Guid: "tag:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rekeyed.ID != post.ID {
+		t.Errorf("GetPostByGuid(tag:1) = %v, want the legacy post %v", rekeyed.ID, post.ID)
+	}
+}
+
+func testRestoreRowsAffected(t *testing.T, s Store) {
+	ctx := context.Background()
+	params := database.RestoreUserParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: "alice"}
+	for i, want := range []int64{1, 0} {
+		got, err := s.RestoreUser(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("RestoreUser() call %d = %d rows, want %d", i+1, got, want)
+		}
+	}
+
+	feed := database.RestoreFeedParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: "Blog", Url: "https://example.com/feed", UserID: params.ID}
+	for i, want := range []int64{1, 0} {
+		got, err := s.RestoreFeed(ctx, feed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("RestoreFeed() call %d = %d rows, want %d", i+1, got, want)
+		}
+	}
+
+	post := database.RestorePostParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Title: "First", Url: "https://example.com/1", FeedID: feed.ID, Guid: "g1"}
+	for i, want := range []int64{1, 0} {
+		got, err := s.RestorePost(ctx, post)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("RestorePost() call %d = %d rows, want %d", i+1, got, want)
+		}
+		// The second attempt conflicts on id alone.
+		post.Guid = "g2"
+	}
+}
+
+func testDeleteUserCascades(t *testing.T, s Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	feed := createFeed(t, s, alice, "Blog", "https://example.com/feed")
+	follow(t, s, bob, feed)
+	createPost(t, s, feed, "g1", "First")
+
+	if err := s.DeleteUser(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	feeds, err := s.ListFeeds(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, err := s.ListPosts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	follows, err := s.ListFeedFollows(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feeds) != 0 || len(posts) != 0 || len(follows) != 0 {
+		t.Errorf("after deleting alice: %d feeds, %d posts, %d follows; want none", len(feeds), len(posts), len(follows))
+	}
+}
+
+func testNextFeedDueSeconds(t *testing.T, s Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	feed := createFeed(t, s, alice, "Blog", "https://example.com/feed")
+	// Scheduled by the database's clock to be due right away.
+	if err := s.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{ID: feed.ID, IntervalSeconds: 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := s.GetNextFeedToFetch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Feed.ID != feed.ID {
+		t.Fatalf("GetNextFeedToFetch() = %s, want %s", next.Feed.Name, feed.Name)
+	}
+	if next.DueSeconds < -1 || next.DueSeconds > 60 {
+		t.Errorf("due seconds = %v, want about 0", next.DueSeconds)
+	}
+}
+
+func testReadTxSnapshot(t *testing.T, s Store) {
+	ctx := context.Background()
+	createUser(t, s, "alice")
+
+	err := s.InReadTx(ctx, func(q Store) error {
+		before, err := q.GetUsers(ctx)
+		if err != nil {
+			return err
+		}
+		createUser(t, s, "bob")
+		after, err := q.GetUsers(ctx)
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(before, after) {
+			t.Errorf("users in the read transaction went from %v to %v, want no change", before, after)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testRollback(t *testing.T, s Store) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+	err := s.InTx(ctx, func(q Store) error {
+		createUser(t, q, "alice")
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("InTx() error = %v, want %v", err, errAbort)
+	}
+
+	users, err := s.GetUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 0 {
+		t.Errorf("GetUsers() after rollback = %v, want none", users)
+	}
+}
+
+// ===== Helper Functions =====
+
+func createUser(t *testing.T, s Store, name string) database.User {
+	t.Helper()
+	user, err := s.CreateUser(context.Background(), database.CreateUserParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      name,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func createFeed(t *testing.T, s Store, user database.User, name, url string) database.Feed {
+	t.Helper()
+	feed, err := s.CreateFeed(context.Background(), database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      name,
+		Url:       url,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return feed
+}
+
+func follow(t *testing.T, s Store, user database.User, feed database.Feed) {
+	t.Helper()
+	_, err := s.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		ID:     uuid.New(),
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func postParams(feed database.Feed, guid, title string) database.UpsertPostParams {
+	return database.UpsertPostParams{
+		ID:          uuid.New(),
+		Title:       title,
+		Url:         "https://example.com/" + guid,
+		Description: sql.NullString{String: title, Valid: true},
+		PublishedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		FeedID:      feed.ID,
+		Guid:        guid,
+	}
+}
+
+func createPost(t *testing.T, s Store, feed database.Feed, guid, title string) database.Post {
+	t.Helper()
+	post, err := s.UpsertPost(context.Background(), postParams(feed, guid, title))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return post
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		dbURL   string
+		backend Backend
+		dsn     string
+	}{
+		{"postgres://gator@localhost:5432/gator?sslmode=disable", Postgres, "postgres://gator@localhost:5432/gator?sslmode=disable"},
+		{"postgresql://localhost/gator", Postgres, "postgresql://localhost/gator"},
+		{"host=localhost user=gator dbname=gator sslmode=disable", Postgres, "host=localhost user=gator dbname=gator sslmode=disable"},
+		{"sqlite:/tmp/gator.db", SQLite, "/tmp/gator.db?" + sqlitePragmas},
+		{"sqlite:///tmp/gator.db", SQLite, "/tmp/gator.db?" + sqlitePragmas},
+		{"file:/tmp/gator.db?cache=shared", SQLite, "file:/tmp/gator.db?cache=shared&" + sqlitePragmas},
+	}
+
+	for _, tt := range tests {
+		backend, dsn, err := ParseURL(tt.dbURL)
+		if err != nil {
+			t.Errorf("ParseURL(%q) error = %v", tt.dbURL, err)
+			continue
+		}
+		if backend != tt.backend || dsn != tt.dsn {
+			t.Errorf("ParseURL(%q) = %s, %q; want %s, %q", tt.dbURL, backend, dsn, tt.backend, tt.dsn)
+		}
+	}
+
+	if _, _, err := ParseURL("sqlite:"); err == nil {
+		t.Error("ParseURL(\"sqlite:\") succeeded, want an error for the missing path")
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/adamararcane/gator/internal/config"
+	"github.com/adamararcane/gator/internal/database"
+)
+
+// newLogger builds the logger described by the log section of the config.
+func newLogger(cfg config.Log, w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if cfg.Level != "" {
+		if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return nil, fmt.Errorf("error parsing log level (debug, info, warn or error): %w", err)
+		}
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch cfg.Format {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("error: unknown log format '%s' (text or json)", cfg.Format)
+	}
+}
+
+// feedLogger returns the default logger with the feed's ID, url and name
+// attached to every record.
+func feedLogger(feed database.Feed) *slog.Logger {
+	return slog.With(slog.Group("feed", "id", feed.ID, "url", feed.Url, "name", feed.Name))
+}
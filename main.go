@@ -3,43 +3,75 @@ package main
 import (
 	"context"
 	"database/sql"
-	"encoding/xml"
+	"errors"
 	"fmt"
-	"html"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/adamararcane/gator/internal/config"
 	"github.com/adamararcane/gator/internal/database"
+	"github.com/adamararcane/gator/internal/htmltext"
+	"github.com/adamararcane/gator/internal/readability"
+	"github.com/adamararcane/gator/internal/store"
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"golang.org/x/term"
 )
 
+// version is reported in the User-Agent feeds are fetched with. Release
+// builds set it with -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
-	// Step 1: Read the config
+	// Until the config says otherwise, log text at info level.
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
+	// Logging is for agg's diagnostics; the error a command ends with is
+	// meant for the user and printed as is.
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	// Step 1: Read the config and set up logging
 	cfgFile, err := config.Read()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error reading config: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("error reading config: %w", err)
+	}
+	logger, err := newLogger(cfgFile.Log, os.Stderr)
+	if err != nil {
+		return fmt.Errorf("error reading config: %w", err)
 	}
+	slog.SetDefault(logger)
 
-	// Step 2: Open a database connection
-	db, err := sql.Open("postgres", cfgFile.Db_url)
+	// Step 2: Open a database connection, picking the backend from db_url
+	backend, dsn, err := store.ParseURL(cfgFile.Db_url)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error opening database: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("error reading config: %w", err)
 	}
+	db, err := sql.Open(backend.DriverName(), dsn)
+	if err != nil {
+		return fmt.Errorf("error opening database: %w", err)
+	}
+	defer db.Close()
 
 	// Step 3: Create database queries instance
-	dbQueries := database.New(db)
+	dbQueries := store.New(backend, db)
 
 	// Step 4: Create application state
-	appState := &state{cfg: cfgFile, db: dbQueries}
+	feedFetcher, err := newFetcher(cfgFile.HTTP)
+	if err != nil {
+		return fmt.Errorf("error reading config: %w", err)
+	}
+	appState := &state{cfg: cfgFile, db: dbQueries, conn: db, backend: backend, fetcher: feedFetcher}
 
 	// Step 5: Define commands and their handlers
 	cmds := commands{command: make(map[string]func(*state, command) error)}
@@ -54,29 +86,61 @@ func main() {
 	cmds.register("follow", middlewareLoggedIn(handlerFollow))
 	cmds.register("following", middlewareLoggedIn(handlerFollowing))
 	cmds.register("unfollow", middlewareLoggedIn(handlerUnfollow))
+	cmds.register("interval", middlewareLoggedIn(handlerInterval))
+	cmds.register("feedauth", middlewareLoggedIn(handlerFeedAuth))
+	cmds.register("removefeed", middlewareLoggedIn(handlerRemoveFeed))
+	cmds.register("deleteuser", middlewareLoggedIn(handlerDeleteUser))
+	cmds.register("promote", middlewareLoggedIn(handlerPromote))
 	cmds.register("browse", middlewareLoggedIn(handlerBrowse))
+	cmds.register("tui", middlewareLoggedIn(handlerTUI))
+	cmds.register("open", middlewareLoggedIn(handlerOpen))
+	cmds.register("read", middlewareLoggedIn(handlerRead))
+	cmds.register("star", middlewareLoggedIn(handlerStar))
+	cmds.register("unstar", middlewareLoggedIn(handlerUnstar))
+	cmds.register("prune", handlerPrune)
+	cmds.register("episodes", middlewareLoggedIn(handlerEpisodes))
+	cmds.register("download", middlewareLoggedIn(handlerDownload))
+	cmds.register("backup", handlerBackup)
+	cmds.register("restore", handlerRestore)
+	cmds.register("migrate", handlerMigrate)
 	cmds.register("help", handlerHelp)
 
 	// Step 6: Check and parse command-line arguments
 	if len(os.Args) < 2 {
-		fmt.Println("error: not enough arguments")
-		os.Exit(1)
+		return fmt.Errorf("error: not enough arguments")
 	}
 
-	// Step 7: Execute given command
+	// Step 7: Make sure the database schema matches this binary
 	name := os.Args[1]
 	args := os.Args[2:]
 	cmd := command{name, args}
 
+	if name != "migrate" && name != "help" {
+		if err := checkSchema(appState); err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+	}
+
+	// Step 8: Execute given command
+
 	if err := cmds.run(appState, cmd); err != nil {
-		fmt.Fprintf(os.Stderr, "error executing command: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("error executing command: %w", err)
 	}
+	return nil
 }
 
 type state struct {
-	cfg config.Config
-	db  *database.Queries
+	cfg     config.Config
+	db      store.Store
+	conn    *sql.DB
+	backend store.Backend
+	fetcher *fetcher
+}
+
+// withTx runs fn with queries bound to a single transaction, committing only
+// if fn succeeds so multi-statement commands never leave partial writes.
+func withTx(s *state, fn func(q store.Store) error) error {
+	return s.db.InTx(context.Background(), fn)
 }
 
 type command struct {
@@ -97,14 +161,15 @@ func handlerLogin(appState *state, cmd command) error {
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Error handling if the user doesn't exist
-			fmt.Fprintf(os.Stderr, "error: username '%s' does not exist\n", username)
-			os.Exit(1)
+			return fmt.Errorf("error: username '%s' does not exist", username)
 		}
 		return fmt.Errorf("error retrieving user: %v", err)
 	}
 
 	// Step 3: Update the configuration to set the logged-in user
-	appState.cfg.SetUser(username)
+	if err := appState.cfg.SetUser(username); err != nil {
+		return fmt.Errorf("error saving logged-in user: %w", err)
+	}
 
 	// Step 4: Provide user feedback for successful login
 	fmt.Printf("User '%s' logged in successfully\n", user.Name)
@@ -126,21 +191,38 @@ func handlerRegister(appState *state, cmd command) error {
 	// Step 3: Get the current time for timestamps
 	now := time.Now()
 
-	// Step 4: Attempt to create the user in the database
-	user, err := appState.db.CreateUser(context.Background(), database.CreateUserParams{
-		ID:        userID,
-		CreatedAt: now,
-		UpdatedAt: now,
-		Name:      username,
+	// Step 4: Create the user and log them in, keeping the user only if the
+	// config could be updated
+	previousUser := appState.cfg.Current_user_name
+	var user database.User
+	err := withTx(appState, func(q store.Store) error {
+		var err error
+		user, err = q.CreateUser(context.Background(), database.CreateUserParams{
+			ID:        userID,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Name:      username,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating user: %v", err)
+		}
+
+		// Step 5: Update the config with the new user and handle any errors
+		if err := appState.cfg.SetUser(username); err != nil {
+			return fmt.Errorf("error saving logged-in user: %w", err)
+		}
+		return nil
 	})
 	if err != nil {
-		// Check if the error is due to a duplicate username and handle appropriately
-		return fmt.Errorf("error creating user: %v", err)
+		// The config may already name a user whose creation was rolled back.
+		if appState.cfg.Current_user_name != previousUser {
+			if restoreErr := appState.cfg.SetUser(previousUser); restoreErr != nil {
+				err = errors.Join(err, fmt.Errorf("error logging back in as '%s': %w", previousUser, restoreErr))
+			}
+		}
+		return err
 	}
 
-	// Step 5: Update the config with the new user and handle any errors
-	appState.cfg.SetUser(username)
-
 	// Step 6: Print success message and debug information
 	fmt.Printf("User created successfully: %v\n", user)
 
@@ -148,13 +230,145 @@ func handlerRegister(appState *state, cmd command) error {
 }
 
 func handlerReset(appState *state, cmd command) error {
-	if len(cmd.args) != 0 {
-		return fmt.Errorf("error: no args needed")
+	// Step 1: Parse the scope of the reset and its options
+	var scope, userName, backupPath string
+	yes := false
+	for i := 0; i < len(cmd.args); i++ {
+		switch arg := cmd.args[i]; arg {
+		case "--yes", "-y":
+			yes = true
+		case "--posts", "--feeds", "--user":
+			if scope != "" {
+				return fmt.Errorf("error: only one of --posts, --feeds or --user can be given")
+			}
+			scope = strings.TrimPrefix(arg, "--")
+			if arg == "--user" {
+				if i+1 >= len(cmd.args) {
+					return fmt.Errorf("error: --user needs a username")
+				}
+				i++
+				userName = cmd.args[i]
+			}
+		case "--backup":
+			if i+1 >= len(cmd.args) {
+				return fmt.Errorf("error: --backup needs a file name")
+			}
+			i++
+			backupPath = cmd.args[i]
+		default:
+			return fmt.Errorf("error: usage: reset [--posts | --feeds | --user <name>] [--backup <file>] [--yes]")
+		}
+	}
+
+	var target database.User
+	prompt := "Delete all users, feeds and posts?"
+	switch scope {
+	case "posts":
+		prompt = "Delete all posts?"
+	case "feeds":
+		prompt = "Delete all feeds and their posts?"
+	case "user":
+		var err error
+		target, err = appState.db.GetUser(context.Background(), userName)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("error: username '%s' does not exist", userName)
+		}
+		if err != nil {
+			return fmt.Errorf("error retrieving user: %w", err)
+		}
+		user, err := appState.db.GetUser(context.Background(), appState.cfg.Current_user_name)
+		if err != nil {
+			return fmt.Errorf("error logging in user: %w", err)
+		}
+		if err := checkCanDeleteUser(appState, user, target); err != nil {
+			return err
+		}
+		prompt = fmt.Sprintf("Delete user '%s' and the feeds they added, with their posts?", target.Name)
+	}
+	if scope != "user" {
+		if err := checkIsAdmin(appState, "reset the database"); err != nil {
+			return err
+		}
 	}
 
-	err := appState.db.ResetDatabase(context.Background())
+	// Step 2: Confirm before deleting anything
+	if !yes {
+		ok, err := confirm(prompt)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Nothing deleted")
+			return nil
+		}
+	}
+
+	// Step 3: Snapshot what is about to be deleted and delete it in one
+	// transaction, so the snapshot holds exactly the rows that went. The
+	// file is created first so a bad path stops the reset before anything
+	// is deleted.
+	var backupFile *os.File
+	if backupPath != "" {
+		var err error
+		backupFile, err = createArchive(backupPath)
+		if err != nil {
+			return err
+		}
+	}
+	var snapshot *archive
+	err := withTx(appState, func(q store.Store) error {
+		if backupFile != nil {
+			var err error
+			snapshot, err = exportArchive(q)
+			if err != nil {
+				return err
+			}
+			switch scope {
+			case "posts":
+				snapshot.keepPosts()
+			case "feeds":
+				snapshot.keepFeeds()
+			case "user":
+				snapshot.keepUser(target.ID)
+			}
+		}
+
+		var err error
+		switch scope {
+		case "posts":
+			err = q.DeleteAllPosts(context.Background())
+		case "feeds":
+			err = q.DeleteAllFeeds(context.Background())
+		case "user":
+			err = q.DeleteUser(context.Background(), target.ID)
+		default:
+			err = q.ResetDatabase(context.Background())
+		}
+		if err != nil {
+			return fmt.Errorf("error reseting database: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error reseting database")
+		if backupFile != nil {
+			backupFile.Close()
+			os.Remove(backupPath)
+		}
+		return err
+	}
+
+	// Step 4: Save the snapshot once the delete has committed
+	if backupFile != nil {
+		if err := encodeArchive(snapshot, backupFile); err != nil {
+			return err
+		}
+		fmt.Printf("Saved a snapshot of the deleted data to %s\n", backupPath)
+	}
+
+	if scope == "user" && appState.cfg.Current_user_name == target.Name {
+		if err := appState.cfg.SetUser(""); err != nil {
+			return fmt.Errorf("error logging out deleted user: %w", err)
+		}
 	}
 
 	fmt.Println("Gator has been reset")
@@ -185,22 +399,130 @@ func handlerGetUsers(appState *state, cmd command) error {
 }
 
 func handlerAgg(appState *state, cmd command) error {
-	if len(cmd.args) < 1 || len(cmd.args) > 2 {
-		return fmt.Errorf("error: input time duration (ex. 1s, 1m, 1hr)")
+	// Step 1: Parse the interval, which --once doesn't need
+	usage := fmt.Errorf("error: usage: agg <duration> (ex. 1s, 1m, 1h) or agg --once, optionally with --metrics <addr>")
+	var once bool
+	var metricsAddr string
+	var args []string
+	for i := 0; i < len(cmd.args); i++ {
+		switch cmd.args[i] {
+		case "--once":
+			once = true
+		case "--metrics":
+			if i+1 == len(cmd.args) {
+				return usage
+			}
+			i++
+			metricsAddr = cmd.args[i]
+		default:
+			args = append(args, cmd.args[i])
+		}
+	}
+	if once && len(args) != 0 || !once && len(args) != 1 {
+		return usage
 	}
 
-	timeDur, err := time.ParseDuration(cmd.args[0])
-	if err != nil {
-		return fmt.Errorf("error parsing time time duration (ex. 1s, 1m, 1hr): %w", err)
+	var timeDur time.Duration
+	if !once {
+		var err error
+		timeDur, err = time.ParseDuration(args[0])
+		if err != nil {
+			return fmt.Errorf("error parsing time time duration (ex. 1s, 1m, 1hr): %w", err)
+		}
+		if timeDur <= 0 {
+			return fmt.Errorf("error: duration must be positive")
+		}
 	}
 
-	fmt.Printf("Collecting feeds every %s...\n", timeDur)
+	if metricsAddr != "" {
+		server, err := serveMetrics(metricsAddr)
+		if err != nil {
+			return err
+		}
+		defer server.Close()
+		slog.Info("serving metrics", "url", "http://"+metricsAddr+"/metrics")
+	}
 
-	ticker := time.NewTicker(timeDur)
-	for ; ; <-ticker.C {
-		scrapeFeeds(appState)
+	// Step 2: Stop on SIGINT or SIGTERM once the fetch in progress is done.
+	// A second signal quits right away.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// Step 3: Collect feeds, remembering which ones failed
+	var fetched int
+	// Feeds are told apart by id, since two feeds can share a name.
+	failed := map[uuid.UUID]bool{}
+	names := map[uuid.UUID]string{}
+	collect := func() (*database.Feed, error) {
+		feed, err := scrapeFeeds(appState)
+		if feed != nil {
+			fetched++
+			failed[feed.ID] = err != nil
+			names[feed.ID] = feed.Name
+		}
+		if err != nil && feed != nil {
+			feedLogger(*feed).Error("couldn't collect feed", "error", err)
+		} else if err != nil {
+			slog.Error("couldn't collect feeds", "error", err)
+		}
+		return feed, err
 	}
 
+	if once {
+		// Every fetch pushes the feed's next fetch into the future, so this
+		// runs out of due feeds. Stop early if one comes around again anyway.
+		seen := map[uuid.UUID]bool{}
+		for ctx.Err() == nil {
+			feed, err := collect()
+			if feed == nil {
+				if err != nil {
+					return err
+				}
+				break
+			}
+			if seen[feed.ID] {
+				break
+			}
+			seen[feed.ID] = true
+		}
+	} else {
+		slog.Info("collecting feeds", "every", timeDur.String())
+
+		ticker := time.NewTicker(timeDur)
+		defer ticker.Stop()
+	loop:
+		for {
+			collect()
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-ticker.C:
+			}
+		}
+	}
+
+	// Step 4: Summarize, failing if any feed's last fetch failed
+	if ctx.Err() != nil {
+		slog.Info("stopped collecting feeds")
+	}
+	var failures []string
+	for id, fail := range failed {
+		if fail {
+			failures = append(failures, htmltext.Line(names[id]))
+		}
+	}
+	if len(failures) > 0 {
+		slices.Sort(failures)
+		return fmt.Errorf("%d of %d feeds failed to collect: %s", len(failures), len(failed), strings.Join(failures, ", "))
+	}
+	if once {
+		slog.Info("collected feeds", "count", fetched)
+	}
+	return nil
 }
 
 func handlerAddFeed(appState *state, cmd command, user database.User) error {
@@ -214,27 +536,40 @@ func handlerAddFeed(appState *state, cmd command, user database.User) error {
 	feedID := uuid.New()
 	now := time.Now()
 
-	feedRecord, err := appState.db.CreateFeed(context.Background(), database.CreateFeedParams{
-		ID:        feedID,
-		CreatedAt: now,
-		UpdatedAt: now,
-		Name:      feedName,
-		Url:       feedUrl,
-		UserID:    user.ID,
-	})
-	if err != nil {
-		return fmt.Errorf("error creating feed")
+	// Adding a feed that was marked as gone brings it back instead.
+	if existing, err := appState.db.GetFeed(context.Background(), feedUrl); err == nil && existing.DeadAt.Valid {
+		return reviveFeed(appState, existing, user)
 	}
 
-	feedFollowParams := database.CreateFeedFollowParams{
-		ID:     uuid.New(),
-		UserID: user.ID,
-		FeedID: feedID,
-	}
+	var feedRecord database.Feed
+	err := withTx(appState, func(q store.Store) error {
+		var err error
+		feedRecord, err = q.CreateFeed(context.Background(), database.CreateFeedParams{
+			ID:        feedID,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Name:      feedName,
+			Url:       feedUrl,
+			UserID:    user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating feed: %w", err)
+		}
+
+		feedFollowParams := database.CreateFeedFollowParams{
+			ID:     uuid.New(),
+			UserID: user.ID,
+			FeedID: feedID,
+		}
 
-	_, err = appState.db.CreateFeedFollow(context.Background(), feedFollowParams)
+		_, err = q.CreateFeedFollow(context.Background(), feedFollowParams)
+		if err != nil {
+			return fmt.Errorf("error creating feed follow: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error creating feed follow: %w", err)
+		return err
 	}
 
 	fmt.Println(feedRecord)
@@ -257,9 +592,16 @@ func handlerGetFeeds(appState *state, cmd command) error {
 		if err != nil {
 			return fmt.Errorf("error matching UUIDs")
 		}
-		fmt.Printf("* %s\n", feed.Name)
-		fmt.Printf("* %s\n", feed.Url)
+		if feed.DeadAt.Valid {
+			fmt.Printf("* %s (gone, no longer fetched)\n", htmltext.Line(feed.Name))
+		} else {
+			fmt.Printf("* %s\n", htmltext.Line(feed.Name))
+		}
+		fmt.Printf("* %s\n", htmltext.Line(feed.Url))
 		fmt.Printf("* %s\n", userName)
+		if feed.ParseWarning.Valid {
+			fmt.Printf("* warning: %s\n", htmltext.Line(feed.ParseWarning.String))
+		}
 	}
 	return nil
 }
@@ -287,7 +629,7 @@ func handlerFollow(appState *state, cmd command, user database.User) error {
 		return fmt.Errorf("error creating feed follow: %w", err)
 	}
 
-	fmt.Printf("%s followed %s\n", feedFollow[0].UserName, feedFollow[0].FeedName)
+	fmt.Printf("%s followed %s\n", feedFollow[0].UserName, htmltext.Line(feedFollow[0].FeedName))
 	return nil
 }
 
@@ -302,7 +644,7 @@ func handlerFollowing(appState *state, cmd command, user database.User) error {
 	}
 
 	for _, name := range userFollowing {
-		fmt.Printf("* %s\n", name.Name)
+		fmt.Printf("* %s\n", htmltext.Line(name.Name))
 	}
 
 	return nil
@@ -314,23 +656,34 @@ func handlerUnfollow(appState *state, cmd command, user database.User) error {
 		return fmt.Errorf("error: url needed")
 	}
 
-	for _, arg := range cmd.args {
-		feed, err := appState.db.GetFeed(context.Background(), arg)
-		if err != nil {
-			return fmt.Errorf("error getting url feed id: %w", err)
-		}
+	// Unfollow every given feed or none of them.
+	var unfollowed []string
+	err := withTx(appState, func(q store.Store) error {
+		for _, arg := range cmd.args {
+			feed, err := q.GetFeed(context.Background(), arg)
+			if err != nil {
+				return fmt.Errorf("error getting url feed id: %w", err)
+			}
 
-		unfollowFeedParams := database.UnfollowFeedParams{
-			FeedID: feed.ID,
-			UserID: user.ID,
-		}
+			unfollowFeedParams := database.UnfollowFeedParams{
+				FeedID: feed.ID,
+				UserID: user.ID,
+			}
 
-		err = appState.db.UnfollowFeed(context.Background(), unfollowFeedParams)
-		if err != nil {
-			return fmt.Errorf("error unfollowing feed: %w", err)
+			err = q.UnfollowFeed(context.Background(), unfollowFeedParams)
+			if err != nil {
+				return fmt.Errorf("error unfollowing feed: %w", err)
+			}
+			unfollowed = append(unfollowed, feed.Name)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-		fmt.Printf("* Unfollowed %s", feed.Name)
+	for _, name := range unfollowed {
+		fmt.Printf("* Unfollowed %s\n", htmltext.Line(name))
 	}
 
 	return nil
@@ -354,32 +707,249 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 		return fmt.Errorf("couldn't get posts for user: %w", err)
 	}
 
+	width := terminalWidth()
 	fmt.Printf("Found %d posts for user %s:\n", len(posts), user.Name)
 	for _, post := range posts {
-		fmt.Printf("%s from %s\n", post.PublishedAt.Time.Format("Mon Jan 2"), post.FeedName)
-		fmt.Printf("--- %s ---\n", post.Title)
-		fmt.Printf("    %v\n", post.Description.String)
-		fmt.Printf("Link: %s\n", post.Url)
+		starred := ""
+		if post.StarredAt.Valid {
+			starred = " (starred)"
+		}
+		fmt.Printf("[%s] %s from %s%s\n", shortID(post.ID), post.PublishedAt.Time.Format("Mon Jan 2"), htmltext.Line(post.FeedName), starred)
+		fmt.Printf("--- %s ---\n", htmltext.Line(post.Title))
+		fmt.Println(indent(htmltext.Render(post.Description.String, width-4), "    "))
+		fmt.Printf("Link: %s\n", htmltext.Line(post.Url))
 		fmt.Println("=====================================")
 	}
 
 	return nil
 }
 
+func handlerOpen(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: post id needed (shown in browse)")
+	}
+
+	post, err := getPostByShortID(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := openBrowser(s.cfg.Browser, post.Url); err != nil {
+		return err
+	}
+
+	err = s.db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("error marking post read: %w", err)
+	}
+
+	fmt.Printf("Opened %s\n", htmltext.Line(post.Url))
+	return nil
+}
+
+func handlerRead(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: post id needed (shown in browse)")
+	}
+
+	post, err := getPostByShortID(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	// Prefer extracted article text, then the full content shipped in the
+	// feed, and only fetch the page when neither is available.
+	width := terminalWidth()
+	var content string
+	switch {
+	case post.Content.Valid:
+		content = htmltext.Wrap(post.Content.String, width)
+	case post.ContentEncoded.Valid:
+		content = htmltext.Render(post.ContentEncoded.String, width)
+	default:
+		extracted, err := extractPostContent(context.Background(), s, post)
+		if err != nil {
+			return err
+		}
+		content = htmltext.Wrap(extracted, width)
+	}
+
+	categories, err := s.db.GetPostCategories(context.Background(), post.ID)
+	if err != nil {
+		return fmt.Errorf("error getting post categories: %w", err)
+	}
+
+	err = s.db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("error marking post read: %w", err)
+	}
+
+	fmt.Printf("--- %s ---\n", htmltext.Line(post.Title))
+	if post.Author.Valid {
+		fmt.Printf("By: %s\n", htmltext.Line(post.Author.String))
+	}
+	if len(categories) > 0 {
+		fmt.Printf("Tags: %s\n", htmltext.Line(strings.Join(categories, ", ")))
+	}
+	fmt.Printf("Link: %s\n", htmltext.Line(post.Url))
+	fmt.Println("=====================================")
+	fmt.Println(content)
+
+	return nil
+}
+
+func handlerStar(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: post id needed (shown in browse)")
+	}
+
+	post, err := getPostByShortID(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	err = s.db.MarkPostStarred(context.Background(), database.MarkPostStarredParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("error starring post: %w", err)
+	}
+
+	fmt.Printf("Starred %s\n", htmltext.Line(post.Title))
+	return nil
+}
+
+func handlerUnstar(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: post id needed (shown in browse)")
+	}
+
+	post, err := getPostByShortID(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	err = s.db.MarkPostUnstarred(context.Background(), database.MarkPostUnstarredParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("error unstarring post: %w", err)
+	}
+
+	fmt.Printf("Unstarred %s\n", htmltext.Line(post.Title))
+	return nil
+}
+
+// extractPostContent fetches the page a post links to and stores its
+// readable article text.
+func extractPostContent(ctx context.Context, s *state, post database.Post) (string, error) {
+	content, err := readability.Fetch(ctx, s.fetcher.client, s.fetcher.userAgent, post.Url)
+	if err != nil {
+		return "", fmt.Errorf("error extracting content from %s: %w", post.Url, err)
+	}
+
+	err = s.db.UpdatePostContent(context.Background(), database.UpdatePostContentParams{
+		ID:      post.ID,
+		Content: sql.NullString{String: content, Valid: true},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error saving post content: %w", err)
+	}
+
+	return content, nil
+}
+
+// terminalWidth returns the width text output is wrapped to, capped so
+// lines stay readable on wide terminals.
+func terminalWidth() int {
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 {
+		return 80
+	}
+	return min(width, 100)
+}
+
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+const shortIDLength = 8
+
+// shortID returns the identifier printed next to posts, a prefix of the
+// post's UUID that is stable for the life of the post.
+func shortID(id uuid.UUID) string {
+	return id.String()[:shortIDLength]
+}
+
+func getPostByShortID(s *state, user database.User, id string) (database.Post, error) {
+	id = strings.ToLower(id)
+	if len(id) < 4 || strings.Trim(id, "0123456789abcdef-") != "" {
+		return database.Post{}, fmt.Errorf("error: invalid post id '%s'", id)
+	}
+
+	// Only posts of feeds the user follows can be looked up.
+	posts, err := s.db.GetPostsByIDPrefix(context.Background(), database.GetPostsByIDPrefixParams{
+		UserID: user.ID,
+		Prefix: id,
+	})
+	if err != nil {
+		return database.Post{}, fmt.Errorf("error getting post: %w", err)
+	}
+
+	switch len(posts) {
+	case 0:
+		return database.Post{}, fmt.Errorf("error: no post with id '%s'", id)
+	case 1:
+		return posts[0], nil
+	default:
+		return database.Post{}, fmt.Errorf("error: post id '%s' is ambiguous, use more characters", id)
+	}
+}
+
 func handlerHelp(s *state, cmd command) error {
 	descriptions := map[string]string{
-		"help":      "Show available commands",
-		"reset":     "Reset the application state",
-		"register":  "Register a new user and log them in",
-		"login":     "Log in as an existing user",
-		"users":     "List all users",
-		"addfeed":   "Add a new feed (requires login)",
-		"feeds":     "List all feeds",
-		"follow":    "Follow a feed (requires login)",
-		"following": "List feeds you are following (requires login)",
-		"unfollow":  "Unfollow a feed (requires login)",
-		"agg":       "Aggregate data",
-		"browse":    "Browse posts from your feeds (requires login)",
+		"help":       "Show available commands",
+		"backup":     "Save all users, feeds, follows, posts and read state to a JSON file",
+		"restore":    "Add the contents of a backup file to the database, skipping what is already there",
+		"migrate":    "Apply, roll back or show database migrations (up|down|status)",
+		"reset":      "Reset the application state",
+		"register":   "Register a new user and log them in",
+		"login":      "Log in as an existing user",
+		"users":      "List all users",
+		"addfeed":    "Add a new feed (requires login)",
+		"feeds":      "List all feeds",
+		"follow":     "Follow a feed (requires login)",
+		"following":  "List feeds you are following (requires login)",
+		"unfollow":   "Unfollow a feed (requires login)",
+		"interval":   "Show or set how often a feed is fetched (<url> [<duration>|auto])",
+		"feedauth":   "Set the credentials of a private feed (<url> [basic <username> | bearer | cookie | none])",
+		"removefeed": "Delete a feed you added, with its posts (requires login; admins can remove any feed)",
+		"deleteuser": "Delete a user and the feeds they added (requires login; admins can delete anyone)",
+		"promote":    "Make another user an admin (requires login as an admin)",
+		"agg":        "Collect feeds that are due, checking every given duration (or --once; --metrics <addr> serves /metrics)",
+		"browse":     "Browse posts from your feeds (requires login)",
+		"tui":        "Read your feeds in a full-screen terminal interface (requires login)",
+		"open":       "Open a post in your browser by its browse id (requires login)",
+		"read":       "Print the article text of a post by its browse id (requires login)",
+		"star":       "Star a post by its browse id so it is never pruned (requires login)",
+		"unstar":     "Remove the star from a post (requires login)",
+		"prune":      "Delete old posts according to the retention settings",
+		"episodes":   "List podcast episodes from your feeds (requires login)",
+		"download":   "Download the media of a podcast episode by its id (requires login)",
 	}
 
 	fmt.Println("Usage: Gator <command> <args>")
@@ -418,84 +988,126 @@ func (c *commands) run(s *state, cmd command) error {
 	return fmt.Errorf("command '%s' not found", cmd.name)
 }
 
-type RSSFeed struct {
-	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
-	} `xml:"channel"`
-}
+// scrapeFeeds collects the feed that is most overdue, returning it, or nil
+// when no feed is due yet.
+func scrapeFeeds(appState *state) (*database.Feed, error) {
+	next, err := appState.db.GetNextFeedToFetch(context.Background())
+	if err == sql.ErrNoRows {
+		dueFeedLag.Set(0)
+		slog.Debug("no feed is due")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting next feed: %w", err)
+	}
+	nextFeed := next.Feed
+	observeDueFeed(next.DueSeconds)
 
-type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	if err := scrapeFeed(appState, nextFeed); err != nil {
+		return &nextFeed, fmt.Errorf("%s: %w", nextFeed.Name, err)
+	}
+	return &nextFeed, nil
 }
 
-func fetchFeed(feedURL string) (*RSSFeed, error) {
+// scrapeFeed fetches nextFeed, stores its new and edited posts and
+// schedules its next fetch.
+func scrapeFeed(appState *state, nextFeed database.Feed) error {
+	logger := feedLogger(nextFeed)
+	logger.Debug("fetching feed")
 
-	var feed RSSFeed
-
-	req, err := http.NewRequest("GET", feedURL, nil)
+	interval, err := fetchInterval(appState, nextFeed)
 	if err != nil {
-		return &RSSFeed{}, fmt.Errorf("error creating request")
+		return err
 	}
-	req.Header.Add("User-Agent", "Gator")
 
-	resp, err := http.DefaultClient.Do(req)
+	err = appState.db.MarkFeedFetched(context.Background(), database.MarkFeedFetchedParams{
+		ID:              nextFeed.ID,
+		IntervalSeconds: int32(interval.Seconds()),
+	})
 	if err != nil {
-		return &RSSFeed{}, fmt.Errorf("error getting rss feed")
+		return fmt.Errorf("error marking feed as fetched: %w", err)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	auth, err := openFeedAuth(appState, nextFeed)
 	if err != nil {
-		return &RSSFeed{}, fmt.Errorf("error reading response body")
+		return err
 	}
 
-	err = xml.Unmarshal(body, &feed)
+	start := time.Now()
+	feedData, err := appState.fetcher.fetchFeed(nextFeed.Url, auth)
+	observeFetch(time.Since(start), err)
 	if err != nil {
-		return &RSSFeed{}, fmt.Errorf("error unmarshaling xml")
+		// A server that is rate limiting us or down for maintenance says when
+		// to come back.
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) && statusErr.retryAfter > interval {
+			if err := deferFetch(appState, nextFeed, statusErr.retryAfter); err != nil {
+				return err
+			}
+		}
+		// A feed that is gone for good isn't fetched again.
+		if errors.As(err, &statusErr) && statusErr.code == http.StatusGone {
+			if err := appState.db.MarkFeedDead(context.Background(), nextFeed.ID); err != nil {
+				return fmt.Errorf("error marking feed as dead: %w", err)
+			}
+			return fmt.Errorf("error fetching feed: %w, it won't be fetched again", err)
+		}
+		return fmt.Errorf("error fetching feed: %w", err)
 	}
 
-	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
-	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
-
-	for i := range feed.Channel.Item {
-		feed.Channel.Item[i].Title = html.UnescapeString(feed.Channel.Item[i].Title)
-		feed.Channel.Item[i].Description = html.UnescapeString(feed.Channel.Item[i].Description)
+	if feedData.movedTo != "" && feedData.movedTo != nextFeed.Url {
+		moved, err := moveFeed(appState, nextFeed, feedData.movedTo)
+		if err != nil {
+			return err
+		}
+		switch {
+		case moved.Url != feedData.movedTo:
+			logger.Warn("feed moved permanently to the url of another user's feed, leaving both alone", "new_url", feedData.movedTo)
+		case moved.ID == nextFeed.ID:
+			logger.Info("feed moved permanently", "new_url", moved.Url)
+		default:
+			logger.Info("feed moved permanently, merged into the feed already at its new url", "new_url", moved.Url, "merged_into", moved.ID)
+		}
+		nextFeed = moved
+		logger = feedLogger(nextFeed)
 	}
 
-	return &feed, nil
-
-}
-
-func scrapeFeeds(appState *state) error {
-	nextFeed, err := appState.db.GetNextFeedToFetch(context.Background())
-	if err != nil {
-		return fmt.Errorf("error getting next feed: %w", err)
+	// Keep the feed's parse warning current, so it goes away once the
+	// publisher fixes their feed.
+	if feedData.warning != nextFeed.ParseWarning.String {
+		if feedData.warning != "" {
+			logger.Warn("feed is malformed", "warning", feedData.warning)
+		}
+		err := appState.db.SetFeedParseWarning(context.Background(), database.SetFeedParseWarningParams{
+			ID:           nextFeed.ID,
+			ParseWarning: nullString(feedData.warning),
+		})
+		if err != nil {
+			return fmt.Errorf("error saving parse warning: %w", err)
+		}
 	}
 
-	err = appState.db.MarkFeedFetched(context.Background(), nextFeed.ID)
-	if err != nil {
-		return fmt.Errorf("error marking feed as fetched: %w", err)
+	// The feed may ask to be polled less often than we would.
+	if delay := politeDelay(interval, feedData, time.Now()); delay > interval {
+		if err := deferFetch(appState, nextFeed, delay); err != nil {
+			return err
+		}
+		interval = delay
 	}
 
-	feedData, err := fetchFeed(nextFeed.Url)
-	if err != nil {
-		return fmt.Errorf("error fetching feed: %w", err)
-	}
+	// Items the retention policy would prune right away aren't stored, so
+	// pruned posts don't come back while the feed still lists them. Nobody
+	// has read these yet, but the unread rule only protects stored posts.
+	cutoff := retentionCutoff(appState.cfg.RetentionFor(nextFeed.Url), feedData.Channel.Item)
 
+	var created, updated int
+	var toExtract []database.Post
 	for _, feedItem := range feedData.Channel.Item {
-		publishedAt := sql.NullTime{}
-		if t, err := time.Parse(time.RFC1123Z, feedItem.PubDate); err == nil {
-			publishedAt = sql.NullTime{
-				Time:  t,
-				Valid: true,
-			}
+		publishedAt := feedItem.publishedAt()
+		if publishedAt.Valid && publishedAt.Time.Before(cutoff) {
+			continue
 		}
-		createPostParams := database.CreatePostParams{
+		upsertPostParams := database.UpsertPostParams{
 			ID:    uuid.New(),
 			Title: feedItem.Title,
 			Url:   feedItem.Link,
@@ -503,21 +1115,257 @@ func scrapeFeeds(appState *state) error {
 				String: feedItem.Description,
 				Valid:  true,
 			},
-			PublishedAt: publishedAt,
-			FeedID:      nextFeed.ID,
+			PublishedAt:     publishedAt,
+			FeedID:          nextFeed.ID,
+			Guid:            feedItem.dedupKey(),
+			Author:          nullString(feedItem.author()),
+			ContentEncoded:  nullString(feedItem.Content),
+			DurationSeconds: feedItem.durationSeconds(),
+			Episode:         parseNullInt32(feedItem.ITunesEpisode),
+			Season:          parseNullInt32(feedItem.ITunesSeason),
+			ImageUrl:        nullString(strings.TrimSpace(feedItem.ITunesImage.Href)),
+		}
+
+		// The upsert returns no row when the item is already stored unchanged,
+		// and the existing post's ID when an edited item was updated.
+		var post database.Post
+		unchanged := false
+		err := withTx(appState, func(q store.Store) error {
+			if upsertPostParams.Guid != upsertPostParams.Url {
+				err := q.RekeyLegacyPost(context.Background(), database.RekeyLegacyPostParams{
+					Guid:   upsertPostParams.Guid,
+					FeedID: upsertPostParams.FeedID,
+					Url:    upsertPostParams.Url,
+				})
+				if err != nil {
+					return fmt.Errorf("error rekeying post: %w", err)
+				}
+			}
+
+			var err error
+			post, err = q.UpsertPost(context.Background(), upsertPostParams)
+			if err == sql.ErrNoRows {
+				unchanged = true
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("error saving post: %w", err)
+			}
+			return savePostMetadata(q, post.ID, feedItem, post.ID != upsertPostParams.ID)
+		})
+		if err != nil {
+			logger.Error("couldn't save post", "post_url", feedItem.Link, "error", err)
+			continue
+		}
+		if unchanged {
+			feedPosts.WithLabelValues(nextFeed.Url, "duplicate").Inc()
+			continue
+		}
+
+		if post.ID == upsertPostParams.ID {
+			created++
+			feedPosts.WithLabelValues(nextFeed.Url, "inserted").Inc()
+		} else {
+			updated++
+			feedPosts.WithLabelValues(nextFeed.Url, "updated").Inc()
+		}
+
+		if appState.cfg.Extract_content && !post.Content.Valid {
+			toExtract = append(toExtract, post)
+		}
+	}
+
+	logger.Info("feed collected", "posts", len(feedData.Channel.Item), "new", created, "updated", updated, "next_fetch_in", interval.String())
+
+	pruned, err := pruneFeed(appState, nextFeed)
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		logger.Info("feed pruned", "removed", pruned)
+	}
+
+	// Extraction fetches a page per post, so it runs once the feed is saved
+	// and only for a few posts per pass. The rest are extracted when read.
+	if len(toExtract) > maxExtractionsPerFetch {
+		logger.Info("leaving article extraction for later", "posts", len(toExtract)-maxExtractionsPerFetch)
+		toExtract = toExtract[:maxExtractionsPerFetch]
+	}
+	for _, post := range toExtract {
+		ctx, cancel := context.WithTimeout(context.Background(), extractTimeout)
+		_, err := extractPostContent(ctx, appState, post)
+		cancel()
+		if err != nil {
+			logger.Warn("couldn't extract post content", "post_url", post.Url, "error", err)
 		}
+	}
+	return nil
+}
 
-		_, err = appState.db.CreatePost(context.Background(), createPostParams)
+// maxExtractionsPerFetch and extractTimeout bound how long agg spends
+// extracting article text after fetching a feed.
+const (
+	maxExtractionsPerFetch = 5
+	extractTimeout         = 10 * time.Second
+)
+
+// reviveFeed clears the gone mark of feed so agg fetches it again, and
+// has user follow it if they don't already.
+func reviveFeed(appState *state, feed database.Feed, user database.User) error {
+	err := withTx(appState, func(q store.Store) error {
+		if err := q.ReviveFeed(context.Background(), feed.ID); err != nil {
+			return fmt.Errorf("error reviving feed: %w", err)
+		}
+
+		follows, err := q.GetFeedFollowsForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("error getting feed follows: %w", err)
+		}
+		if slices.ContainsFunc(follows, func(f database.GetFeedFollowsForUserRow) bool { return f.ID == feed.ID }) {
+			return nil
+		}
+		_, err = q.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+			ID:     uuid.New(),
+			UserID: user.ID,
+			FeedID: feed.ID,
+		})
 		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-				continue
+			return fmt.Errorf("error creating feed follow: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s was marked as gone; agg will fetch it again\n", htmltext.Line(feed.Name))
+	return nil
+}
+
+// moveFeed changes the url of feed to newURL. If another feed of the same
+// creator already has that url, feed is merged into it instead: its
+// followers, the posts the other feed doesn't have, and its credentials and
+// manual interval where the other feed has none move over and feed is
+// deleted. It returns the feed now at newURL, or feed unchanged when
+// another user's feed has that url.
+func moveFeed(appState *state, feed database.Feed, newURL string) (database.Feed, error) {
+	var moved database.Feed
+	err := withTx(appState, func(q store.Store) error {
+		existing, err := q.GetFeed(context.Background(), newURL)
+		if err == sql.ErrNoRows {
+			err := q.SetFeedUrl(context.Background(), database.SetFeedUrlParams{ID: feed.ID, Url: newURL})
+			if err != nil {
+				return fmt.Errorf("error updating feed url: %w", err)
+			}
+			moved = feed
+			moved.Url = newURL
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error getting feed: %w", err)
+		}
+		// Merging would hand the feed to someone else and drop its settings.
+		if existing.UserID != feed.UserID {
+			moved = feed
+			return nil
+		}
+
+		if existing.Auth == nil && feed.Auth != nil {
+			// Credentials are sealed to their feed's id.
+			auth, err := openFeedAuth(appState, feed)
+			if err != nil {
+				return err
 			}
-			log.Printf("Couldn't create post: %v", err)
+			existing.Auth, err = sealFeedAuth(appState, existing, *auth)
+			if err != nil {
+				return err
+			}
+			err = q.SetFeedAuth(context.Background(), database.SetFeedAuthParams{ID: existing.ID, Auth: existing.Auth})
+			if err != nil {
+				return fmt.Errorf("error moving credentials: %w", err)
+			}
+		}
+		if !existing.FetchIntervalSeconds.Valid && feed.FetchIntervalSeconds.Valid {
+			existing.FetchIntervalSeconds = feed.FetchIntervalSeconds
+			err := q.SetFeedFetchInterval(context.Background(), database.SetFeedFetchIntervalParams{
+				ID:                   existing.ID,
+				FetchIntervalSeconds: existing.FetchIntervalSeconds,
+			})
+			if err != nil {
+				return fmt.Errorf("error moving interval: %w", err)
+			}
+		}
+
+		err = q.MoveFeedFollows(context.Background(), database.MoveFeedFollowsParams{FromFeedID: feed.ID, ToFeedID: existing.ID})
+		if err != nil {
+			return fmt.Errorf("error moving follows: %w", err)
+		}
+		// Posts the target feed already has stay behind and go with the old
+		// feed, so their read and starred marks are copied over first.
+		err = q.MoveFeedPostStates(context.Background(), database.MoveFeedPostStatesParams{FromFeedID: feed.ID, ToFeedID: existing.ID})
+		if err != nil {
+			return fmt.Errorf("error moving post states: %w", err)
+		}
+		err = q.MoveFeedPosts(context.Background(), database.MoveFeedPostsParams{FromFeedID: feed.ID, ToFeedID: existing.ID})
+		if err != nil {
+			return fmt.Errorf("error moving posts: %w", err)
+		}
+		if err := q.DeleteFeed(context.Background(), feed.ID); err != nil {
+			return fmt.Errorf("error removing merged feed: %w", err)
+		}
+		moved = existing
+		return nil
+	})
+	return moved, err
+}
+
+// savePostMetadata stores the categories and enclosures of a feed item,
+// replacing those already stored when the post is being updated.
+func savePostMetadata(q store.Store, postID uuid.UUID, feedItem RSSItem, replace bool) error {
+	if replace {
+		if err := q.DeletePostCategories(context.Background(), postID); err != nil {
+			return fmt.Errorf("error clearing categories: %w", err)
+		}
+		if err := q.DeletePostEnclosures(context.Background(), postID); err != nil {
+			return fmt.Errorf("error clearing enclosures: %w", err)
+		}
+	}
+
+	for _, category := range feedItem.Categories {
+		if category == "" {
 			continue
 		}
+		err := q.AddPostCategory(context.Background(), database.AddPostCategoryParams{
+			PostID: postID,
+			Name:   category,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding category: %w", err)
+		}
+	}
 
+	for _, enclosure := range feedItem.Enclosures {
+		if enclosure.URL == "" {
+			continue
+		}
+		length := sql.NullInt64{}
+		if n, err := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64); err == nil && n > 0 {
+			length = sql.NullInt64{Int64: n, Valid: true}
+		}
+		err := q.AddPostEnclosure(context.Background(), database.AddPostEnclosureParams{
+			PostID: postID,
+			Url:    enclosure.URL,
+			Type:   enclosure.Type,
+			Length: length,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding enclosure: %w", err)
+		}
 	}
 
-	log.Printf("Feed %s collected, %v posts found", nextFeed.Name, len(feedData.Channel.Item))
 	return nil
 }
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
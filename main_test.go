@@ -0,0 +1,514 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adamararcane/gator/internal/config"
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/adamararcane/gator/internal/store"
+	"github.com/google/uuid"
+)
+
+func TestRegister(t *testing.T) {
+	s, cfgPath := newTestState(t)
+
+	out, err := runHandler(t, s, handlerRegister, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "User created successfully") {
+		t.Errorf("register printed %q, want a success message", out)
+	}
+	if user := savedUser(t, cfgPath); user != "alice" {
+		t.Errorf("config names %q as logged in, want alice", user)
+	}
+
+	if _, err := runHandler(t, s, handlerRegister, "alice"); err == nil {
+		t.Error("registering alice twice succeeded, want an error")
+	}
+	if _, err := runHandler(t, s, handlerRegister); err == nil {
+		t.Error("register without a name succeeded, want an error")
+	}
+}
+
+func TestRegisterKeepsLoginWhenItFails(t *testing.T) {
+	s, cfgPath := newTestState(t)
+	mustRun(t, s, handlerRegister, "alice")
+	mustRun(t, s, handlerRegister, "bob")
+	mustRun(t, s, handlerLogin, "alice")
+
+	if _, err := runHandler(t, s, handlerRegister, "bob"); err == nil {
+		t.Fatal("registering bob twice succeeded, want an error")
+	}
+	if user := savedUser(t, cfgPath); user != "alice" {
+		t.Errorf("config names %q as logged in after a failed register, want alice", user)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	s, cfgPath := newTestState(t)
+
+	_, err := runHandler(t, s, handlerLogin, "alice")
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("login as an unknown user: error = %v, want 'does not exist'", err)
+	}
+
+	mustRun(t, s, handlerRegister, "alice")
+	mustRun(t, s, handlerRegister, "bob")
+	out, err := runHandler(t, s, handlerLogin, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "User 'alice' logged in successfully") {
+		t.Errorf("login printed %q, want a success message", out)
+	}
+	if user := savedUser(t, cfgPath); user != "alice" {
+		t.Errorf("config names %q as logged in, want alice", user)
+	}
+}
+
+func TestAddFeed(t *testing.T) {
+	s, _ := newTestState(t)
+
+	if _, err := runHandler(t, s, middlewareLoggedIn(handlerAddFeed), "Blog", "https://example.com/feed"); err == nil {
+		t.Error("addfeed while logged out succeeded, want an error")
+	}
+
+	mustRun(t, s, handlerRegister, "alice")
+	if _, err := runHandler(t, s, middlewareLoggedIn(handlerAddFeed), "Blog"); err == nil {
+		t.Error("addfeed without a url succeeded, want an error")
+	}
+
+	out, err := runHandler(t, s, middlewareLoggedIn(handlerAddFeed), "Blog", "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "https://example.com/feed") {
+		t.Errorf("addfeed printed %q, want the new feed", out)
+	}
+
+	// Adding a feed follows it too.
+	out = mustRun(t, s, middlewareLoggedIn(handlerFollowing))
+	if out != "* Blog\n" {
+		t.Errorf("following printed %q, want the new feed", out)
+	}
+
+	if _, err := runHandler(t, s, middlewareLoggedIn(handlerAddFeed), "Again", "https://example.com/feed"); err == nil {
+		t.Error("adding the same url twice succeeded, want an error")
+	}
+}
+
+func TestFollowAndUnfollow(t *testing.T) {
+	s, _ := newTestState(t)
+	mustRun(t, s, handlerRegister, "alice")
+	mustRun(t, s, middlewareLoggedIn(handlerAddFeed), "Blog", "https://example.com/feed")
+	mustRun(t, s, handlerRegister, "bob")
+
+	out, err := runHandler(t, s, middlewareLoggedIn(handlerFollow), "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "bob followed Blog\n" {
+		t.Errorf("follow printed %q, want %q", out, "bob followed Blog\n")
+	}
+
+	if _, err := runHandler(t, s, middlewareLoggedIn(handlerFollow), "https://example.com/other"); err == nil {
+		t.Error("following an unknown url succeeded, want an error")
+	}
+	if _, err := runHandler(t, s, middlewareLoggedIn(handlerFollow), "https://example.com/feed"); err == nil {
+		t.Error("following a feed twice succeeded, want an error")
+	}
+
+	out, err = runHandler(t, s, middlewareLoggedIn(handlerUnfollow), "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "* Unfollowed Blog\n" {
+		t.Errorf("unfollow printed %q, want %q", out, "* Unfollowed Blog\n")
+	}
+	if out := mustRun(t, s, middlewareLoggedIn(handlerFollowing)); out != "" {
+		t.Errorf("following after unfollow printed %q, want nothing", out)
+	}
+
+	if _, err := runHandler(t, s, middlewareLoggedIn(handlerUnfollow), "https://example.com/other"); err == nil {
+		t.Error("unfollowing an unknown url succeeded, want an error")
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	s, _ := newTestState(t)
+	mustRun(t, s, handlerRegister, "alice")
+	mustRun(t, s, middlewareLoggedIn(handlerAddFeed), "Blog", "https://example.com/feed")
+	feed, err := s.db.GetFeed(context.Background(), "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.db.UpsertPost(context.Background(), database.UpsertPostParams{
+		ID:          uuid.New(),
+		Title:       "Hello \x1b[31mworld",
+		Url:         "https://example.com/hello",
+		Description: sql.NullString{String: "<p>First post</p>", Valid: true},
+		FeedID:      feed.ID,
+		Guid:        "hello",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runHandler(t, s, middlewareLoggedIn(handlerBrowse), "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Found 1 posts for user alice", "from Blog", "--- Hello [31mworld ---", "    First post", "Link: https://example.com/hello"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("browse printed %q, want it to contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "\x1b") {
+		t.Errorf("browse printed an escape character from the feed: %q", out)
+	}
+
+	if _, err := runHandler(t, s, middlewareLoggedIn(handlerBrowse), "many"); err == nil {
+		t.Error("browse with a non-numeric limit succeeded, want an error")
+	}
+
+	// Posts only show up for followers of their feed.
+	mustRun(t, s, handlerRegister, "bob")
+	out = mustRun(t, s, middlewareLoggedIn(handlerBrowse), "10")
+	if !strings.Contains(out, "Found 0 posts for user bob") {
+		t.Errorf("browse as bob printed %q, want no posts", out)
+	}
+}
+
+func TestDownloadKeepsEpisodesWithTheSameFileNameApart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "audio from %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	s, _ := newTestState(t)
+	fetcher, err := newFetcher(config.HTTP{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.fetcher = fetcher
+	mustRun(t, s, handlerRegister, "alice")
+	s.cfg.Download_dir = t.TempDir()
+	mustRun(t, s, middlewareLoggedIn(handlerAddFeed), "Podcast", "https://example.com/feed")
+	feed, err := s.db.GetFeed(context.Background(), "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var posts []database.Post
+	for _, episode := range []string{"one", "two"} {
+		post, err := s.db.UpsertPost(context.Background(), database.UpsertPostParams{
+			ID:     uuid.New(),
+			Title:  "Episode " + episode,
+			Url:    "https://example.com/" + episode,
+			FeedID: feed.ID,
+			Guid:   episode,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.db.AddPostEnclosure(context.Background(), database.AddPostEnclosureParams{
+			PostID: post.ID,
+			Url:    server.URL + "/" + episode + "/default_tc.mp3",
+			Type:   "audio/mpeg",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		posts = append(posts, post)
+	}
+
+	for _, post := range posts {
+		mustRun(t, s, middlewareLoggedIn(handlerDownload), shortID(post.ID))
+	}
+	for i, episode := range []string{"one", "two"} {
+		name := filepath.Join(s.cfg.Download_dir, "Podcast", shortID(posts[i].ID)+"-default_tc.mp3")
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "audio from /" + episode + "/default_tc.mp3"; string(data) != want {
+			t.Errorf("%s holds %q, want %q", name, data, want)
+		}
+	}
+}
+
+func TestResetNeedsAnAdmin(t *testing.T) {
+	s, _ := newTestState(t)
+	mustRun(t, s, handlerRegister, "alice")
+	mustRun(t, s, middlewareLoggedIn(handlerAddFeed), "Blog", "https://example.com/feed")
+	mustRun(t, s, handlerRegister, "bob")
+
+	for _, args := range [][]string{{"--yes"}, {"--posts", "--yes"}, {"--feeds", "--yes"}, {"--user", "alice", "--yes"}} {
+		if _, err := runHandler(t, s, handlerReset, args...); err == nil || !strings.Contains(err.Error(), "only an admin") {
+			t.Errorf("reset %v as bob: error = %v, want 'only an admin'", args, err)
+		}
+	}
+	if _, err := s.db.GetFeed(context.Background(), "https://example.com/feed"); err != nil {
+		t.Fatalf("feed is gone after refused resets: %v", err)
+	}
+
+	mustRun(t, s, handlerReset, "--user", "bob", "--yes")
+	mustRun(t, s, handlerLogin, "alice")
+	mustRun(t, s, handlerReset, "--feeds", "--yes")
+	if _, err := s.db.GetFeed(context.Background(), "https://example.com/feed"); err != sql.ErrNoRows {
+		t.Errorf("getting the feed after reset --feeds: error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestResetBackup(t *testing.T) {
+	s, _ := newTestState(t)
+	mustRun(t, s, handlerRegister, "alice")
+	mustRun(t, s, middlewareLoggedIn(handlerAddFeed), "Blog", "https://example.com/feed")
+	backupPath := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := os.WriteFile(backupPath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runHandler(t, s, handlerReset, "--feeds", "--backup", backupPath, "--yes"); err == nil {
+		t.Fatal("reset with an existing backup file succeeded, want an error")
+	}
+	if _, err := s.db.GetFeed(context.Background(), "https://example.com/feed"); err != nil {
+		t.Fatalf("feed is gone after a refused reset: %v", err)
+	}
+
+	if err := os.Remove(backupPath); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, s, handlerReset, "--feeds", "--backup", backupPath, "--yes")
+	a, err := readArchive(backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Feeds) != 1 || a.Feeds[0].Url != "https://example.com/feed" || len(a.Users) != 0 {
+		t.Errorf("snapshot holds users %v and feeds %v, want just the deleted feed", a.Users, a.Feeds)
+	}
+}
+
+func TestBackupKeepsFeedState(t *testing.T) {
+	s, _ := newTestState(t)
+	mustRun(t, s, handlerRegister, "alice")
+	mustRun(t, s, middlewareLoggedIn(handlerAddFeed), "Blog", "https://example.com/feed")
+	ctx := context.Background()
+	feed, err := s.db.GetFeed(ctx, "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{ID: feed.ID, IntervalSeconds: 3600}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.SetFeedParseWarning(ctx, database.SetFeedParseWarningParams{ID: feed.ID, ParseWarning: sql.NullString{String: "bad entity", Valid: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.MarkFeedDead(ctx, feed.ID); err != nil {
+		t.Fatal(err)
+	}
+	feed, err = s.db.GetFeed(ctx, "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := exportArchive(s.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := store.NewMemory()
+	if _, err := importArchive(restored, a); err != nil {
+		t.Fatal(err)
+	}
+	got, err := restored.GetFeed(ctx, "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.NextFetchAt.Valid || !got.NextFetchAt.Time.Equal(feed.NextFetchAt.Time) {
+		t.Errorf("restored next_fetch_at = %v, want %v", got.NextFetchAt, feed.NextFetchAt)
+	}
+	if !got.DeadAt.Valid {
+		t.Error("restored feed isn't marked dead")
+	}
+	if got.ParseWarning.String != "bad entity" {
+		t.Errorf("restored parse_warning = %q, want %q", got.ParseWarning.String, "bad entity")
+	}
+}
+
+func TestMoveFeed(t *testing.T) {
+	s, _ := newTestState(t)
+	ctx := context.Background()
+	t.Setenv(config.SecretKeyEnv, "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+	mustRun(t, s, handlerRegister, "alice")
+	mustRun(t, s, middlewareLoggedIn(handlerAddFeed), "Old", "http://example.com/feed")
+	mustRun(t, s, middlewareLoggedIn(handlerAddFeed), "New", "https://example.com/feed")
+	old, err := s.db.GetFeed(ctx, "http://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := sealFeedAuth(s, old, feedAuth{Type: "bearer", Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.SetFeedAuth(ctx, database.SetFeedAuthParams{ID: old.ID, Auth: sealed}); err != nil {
+		t.Fatal(err)
+	}
+	interval := sql.NullInt32{Int32: 3600, Valid: true}
+	if err := s.db.SetFeedFetchInterval(ctx, database.SetFeedFetchIntervalParams{ID: old.ID, FetchIntervalSeconds: interval}); err != nil {
+		t.Fatal(err)
+	}
+	old, err = s.db.GetFeed(ctx, "http://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another user's feed at the new url is left alone.
+	mustRun(t, s, handlerRegister, "bob")
+	mustRun(t, s, middlewareLoggedIn(handlerAddFeed), "Bob's", "https://example.com/bob")
+	moved, err := moveFeed(s, old, "https://example.com/bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.ID != old.ID || moved.Url != old.Url {
+		t.Errorf("moving onto bob's feed returned %s at %s, want alice's feed unchanged", moved.Name, moved.Url)
+	}
+	if _, err := s.db.GetFeed(ctx, "http://example.com/feed"); err != nil {
+		t.Errorf("alice's feed is gone after moving onto bob's: %v", err)
+	}
+
+	// The same creator's feed takes over the credentials and interval.
+	moved, err = moveFeed(s, old, "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.Name != "New" {
+		t.Fatalf("moving onto alice's other feed returned %s, want New", moved.Name)
+	}
+	merged, err := s.db.GetFeed(ctx, "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.FetchIntervalSeconds != interval {
+		t.Errorf("merged feed's interval = %v, want %v", merged.FetchIntervalSeconds, interval)
+	}
+	auth, err := openFeedAuth(s, merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth == nil || auth.Token != "secret" {
+		t.Errorf("merged feed's credentials = %+v, want the bearer token", auth)
+	}
+}
+
+func TestReviveFeed(t *testing.T) {
+	s, _ := newTestState(t)
+	ctx := context.Background()
+	mustRun(t, s, handlerRegister, "alice")
+	mustRun(t, s, middlewareLoggedIn(handlerAddFeed), "Blog", "https://example.com/feed")
+	feed, err := s.db.GetFeed(ctx, "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.db.MarkFeedDead(ctx, feed.ID); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, s, middlewareLoggedIn(handlerInterval), "https://example.com/feed", "auto")
+	if feed, err = s.db.GetFeed(ctx, feed.Url); err != nil || feed.DeadAt.Valid {
+		t.Errorf("after interval: dead_at = %v, error = %v; want the feed revived", feed.DeadAt, err)
+	}
+
+	if err := s.db.MarkFeedDead(ctx, feed.ID); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, s, handlerRegister, "bob")
+	out := mustRun(t, s, middlewareLoggedIn(handlerAddFeed), "Blog", "https://example.com/feed")
+	if !strings.Contains(out, "agg will fetch it again") {
+		t.Errorf("addfeed of a gone feed printed %q, want it to be revived", out)
+	}
+	if feed, err = s.db.GetFeed(ctx, feed.Url); err != nil || feed.DeadAt.Valid {
+		t.Errorf("after addfeed: dead_at = %v, error = %v; want the feed revived", feed.DeadAt, err)
+	}
+	if out := mustRun(t, s, middlewareLoggedIn(handlerFollowing)); !strings.Contains(out, "Blog") {
+		t.Errorf("following as bob printed %q, want the revived feed", out)
+	}
+}
+
+func TestOpenBrowserOnlyOpensWebURLs(t *testing.T) {
+	for _, url := range []string{"--new-window=evil", "-oProxyCommand=touch /tmp/x", "file:///etc/passwd", "javascript:alert(1)", "https://"} {
+		if err := openBrowser("true", url); err == nil {
+			t.Errorf("openBrowser(%q) succeeded, want an error", url)
+		}
+	}
+	if err := openBrowser("true", "https://example.com/post"); err != nil {
+		t.Errorf("openBrowser of an https url: %v", err)
+	}
+}
+
+// ===== Helper Functions =====
+
+// newTestState returns a state backed by an in-memory store and a config
+// file in a temporary directory, and the path of that file.
+func newTestState(t *testing.T) (*state, string) {
+	t.Helper()
+	cfgPath := filepath.Join(t.TempDir(), ".gatorconfig.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"db_url": "memory", "current_user_name": ""}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &state{cfg: cfg, db: store.NewMemory()}, cfgPath
+}
+
+// runHandler runs handler with args and returns what it printed to stdout.
+func runHandler(t *testing.T, s *state, handler func(*state, command) error, args ...string) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	printed := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		printed <- string(out)
+	}()
+
+	err = handler(s, command{args: args})
+	w.Close()
+	return <-printed, err
+}
+
+func mustRun(t *testing.T, s *state, handler func(*state, command) error, args ...string) string {
+	t.Helper()
+	out, err := runHandler(t, s, handler, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// savedUser returns the logged-in user recorded in the config file.
+func savedUser(t *testing.T, cfgPath string) string {
+	t.Helper()
+	cfg, err := config.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg.Current_user_name
+}
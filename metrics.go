@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Aggregator metrics, served on /metrics by `agg --metrics <addr>`.
+var (
+	feedFetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gator",
+		Name:      "feed_fetches_total",
+		Help:      "Feed fetches by outcome.",
+	}, []string{"outcome"})
+
+	feedFetchDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "gator",
+		Name:      "feed_fetch_duration_seconds",
+		Help:      "How long fetching a feed took, including reading the response.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 11),
+	})
+
+	feedFetchBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "gator",
+		Name:      "feed_fetch_bytes_total",
+		Help:      "Bytes of feed responses downloaded.",
+	})
+
+	feedPosts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gator",
+		Name:      "feed_posts_total",
+		Help:      "Items of fetched feeds by feed url and result: inserted as new posts, updated, or duplicates of stored posts.",
+	}, []string{"feed", "result"})
+
+	dueFeedLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "gator",
+		Name:      "due_feed_lag_seconds",
+		Help:      "How long the most overdue feed had been due when it was picked up, or 0 when none was due.",
+	})
+)
+
+// serveMetrics serves /metrics on addr in the background. Listening
+// happens before it returns, so a taken port is reported right away.
+func serveMetrics(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error listening for metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			slog.Error("metrics server stopped", "error", err)
+		}
+	}()
+	return server, nil
+}
+
+// observeFetch records a fetch that took elapsed and failed with err, if
+// it failed.
+func observeFetch(elapsed time.Duration, err error) {
+	feedFetchDuration.Observe(elapsed.Seconds())
+	feedFetches.WithLabelValues(fetchOutcome(err)).Inc()
+}
+
+// fetchOutcome names the fetch error err for the outcome label.
+func fetchOutcome(err error) string {
+	var statusErr *httpStatusError
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, errTimeout):
+		return "timeout"
+	case errors.Is(err, errConnection):
+		return "connection_error"
+	case errors.Is(err, errTooManyRedirects):
+		return "too_many_redirects"
+	case errors.Is(err, errBodyTooLarge):
+		return "body_too_large"
+	case errors.Is(err, errInvalidFeed):
+		return "invalid_feed"
+	case errors.As(err, &statusErr):
+		return fmt.Sprintf("http_%dxx", statusErr.code/100)
+	default:
+		return "error"
+	}
+}
+
+// observeDueFeed records how long the feed picked up had been due, in
+// seconds: since its scheduled fetch, or since it was added or rescheduled
+// if it has none.
+func observeDueFeed(dueSeconds float64) {
+	dueFeedLag.Set(max(dueSeconds, 0))
+}
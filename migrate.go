@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/adamararcane/gator/internal/store"
+	"github.com/pressly/goose/v3"
+)
+
+//go:embed sql/schema/*.sql
+var postgresMigrations embed.FS
+
+//go:embed sql/sqlite/schema/*.sql
+var sqliteMigrations embed.FS
+
+func newMigrationProvider(s *state) (*goose.Provider, error) {
+	dialect, fsys, dir := goose.DialectPostgres, fs.FS(postgresMigrations), "sql/schema"
+	if s.backend == store.SQLite {
+		dialect, fsys, dir = goose.DialectSQLite3, sqliteMigrations, "sql/sqlite/schema"
+	}
+
+	migrations, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error loading migrations: %w", err)
+	}
+
+	provider, err := goose.NewProvider(dialect, s.conn, migrations)
+	if err != nil {
+		return nil, fmt.Errorf("error loading migrations: %w", err)
+	}
+	return provider, nil
+}
+
+func handlerMigrate(s *state, cmd command) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: usage: migrate up|down|status")
+	}
+
+	provider, err := newMigrationProvider(s)
+	if err != nil {
+		return err
+	}
+
+	switch cmd.args[0] {
+	case "up":
+		results, err := provider.Up(context.Background())
+		for _, result := range results {
+			fmt.Println(result)
+		}
+		if err != nil {
+			return fmt.Errorf("error applying migrations: %w", err)
+		}
+		if len(results) == 0 {
+			fmt.Println("Database schema is already up to date")
+		}
+
+	case "down":
+		result, err := provider.Down(context.Background())
+		if result != nil {
+			fmt.Println(result)
+		}
+		if err != nil {
+			return fmt.Errorf("error rolling back migration: %w", err)
+		}
+
+	case "status":
+		statuses, err := provider.Status(context.Background())
+		if err != nil {
+			return fmt.Errorf("error getting migration status: %w", err)
+		}
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.State == goose.StateApplied {
+				appliedAt = status.AppliedAt.Local().Format("Mon Jan 2 15:04:05 2006")
+			}
+			fmt.Printf("* %-24s -- %s\n", appliedAt, status.Source.Path)
+		}
+
+	default:
+		return fmt.Errorf("error: unknown migrate command '%s' (up, down or status)", cmd.args[0])
+	}
+
+	return nil
+}
+
+// checkSchema refuses to run against a database whose schema doesn't match
+// the migrations embedded in this binary.
+func checkSchema(s *state) error {
+	provider, err := newMigrationProvider(s)
+	if err != nil {
+		return err
+	}
+
+	current, target, err := provider.GetVersions(context.Background())
+	if err != nil {
+		return fmt.Errorf("error checking database schema: %w", err)
+	}
+
+	if current < target {
+		return fmt.Errorf("database schema is out of date (version %d, need %d); run 'gator migrate up'", current, target)
+	}
+	if current > target {
+		return fmt.Errorf("database schema (version %d) is newer than this version of gator supports (%d)", current, target)
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/adamararcane/gator/internal/htmltext"
+)
+
+func handlerEpisodes(s *state, cmd command, user database.User) error {
+	limit := 20
+	if len(cmd.args) == 1 {
+		if specifiedLimit, err := strconv.Atoi(cmd.args[0]); err == nil {
+			limit = specifiedLimit
+		} else {
+			return fmt.Errorf("invalid limit: %w", err)
+		}
+	}
+
+	episodes, err := s.db.GetEpisodesForUser(context.Background(), database.GetEpisodesForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get episodes for user: %w", err)
+	}
+
+	fmt.Printf("Found %d episodes for user %s:\n", len(episodes), user.Name)
+	for _, episode := range episodes {
+		number := ""
+		if episode.Season.Valid && episode.Episode.Valid {
+			number = fmt.Sprintf("S%dE%d ", episode.Season.Int32, episode.Episode.Int32)
+		} else if episode.Episode.Valid {
+			number = fmt.Sprintf("#%d ", episode.Episode.Int32)
+		}
+
+		var details []string
+		if episode.DurationSeconds.Valid {
+			details = append(details, formatDuration(int(episode.DurationSeconds.Int32)))
+		}
+		if episode.EnclosureLength.Valid {
+			details = append(details, formatBytes(episode.EnclosureLength.Int64))
+		}
+		if episode.EnclosureType != "" {
+			details = append(details, htmltext.Line(episode.EnclosureType))
+		}
+
+		fmt.Printf("[%s] %s from %s\n", shortID(episode.ID), episode.PublishedAt.Time.Format("Mon Jan 2"), htmltext.Line(episode.FeedName))
+		fmt.Printf("--- %s%s ---\n", number, htmltext.Line(episode.Title))
+		if len(details) > 0 {
+			fmt.Printf("    %s\n", strings.Join(details, ", "))
+		}
+	}
+
+	return nil
+}
+
+func handlerDownload(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: episode id needed (shown in episodes)")
+	}
+
+	post, err := getPostByShortID(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	enclosures, err := s.db.GetPostEnclosures(context.Background(), post.ID)
+	if err != nil {
+		return fmt.Errorf("error getting enclosures: %w", err)
+	}
+	if len(enclosures) == 0 {
+		return fmt.Errorf("error: post '%s' has no media to download", post.Title)
+	}
+
+	feed, err := s.db.GetFeedByID(context.Background(), post.FeedID)
+	if err != nil {
+		return fmt.Errorf("error getting feed: %w", err)
+	}
+
+	dir, err := downloadDir(s)
+	if err != nil {
+		return err
+	}
+	// A feed named "..", "." or nothing at all would escape the download
+	// directory, so those feeds get a folder named after their id instead.
+	folder := safeFileName(feed.Name)
+	if folder == "" || folder == "." || folder == ".." {
+		folder = feed.ID.String()
+	}
+	dir = filepath.Join(dir, folder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating download directory: %w", err)
+	}
+
+	for _, enclosure := range enclosures {
+		name := enclosureFileName(enclosure, post)
+		if err := downloadFile(s.fetcher, enclosure.Url, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downloadDir returns the configured download directory, defaulting to
+// ~/Downloads/gator.
+func downloadDir(s *state) (string, error) {
+	if s.cfg.Download_dir != "" {
+		return s.cfg.Download_dir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, "Downloads", "gator"), nil
+}
+
+// downloadFile streams rawURL into dest with the fetcher's client. Data is
+// written to dest.part first so an interrupted download is resumed with a
+// Range request next time.
+func downloadFile(f *fetcher, rawURL, dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		fmt.Printf("Already downloaded %s\n", dest)
+		return nil
+	}
+
+	partPath := dest + ".part"
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", partPath, err)
+	}
+	defer file.Close()
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", partPath, err)
+	}
+
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Add("User-Agent", f.userAgent)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := f.downloadClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("error downloading %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		fmt.Printf("Resuming %s at %s\n", filepath.Base(dest), formatBytes(offset))
+	case http.StatusOK:
+		// The server ignored the Range header, so start over.
+		if offset > 0 {
+			if err := file.Truncate(0); err != nil {
+				return fmt.Errorf("error truncating %s: %w", partPath, err)
+			}
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("error truncating %s: %w", partPath, err)
+			}
+			offset = 0
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file already holds everything the server has.
+		file.Close()
+		return finishDownload(partPath, dest)
+	default:
+		return fmt.Errorf("error downloading %s: %s", rawURL, resp.Status)
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	progress := &progressWriter{name: filepath.Base(dest), written: offset, total: total}
+
+	_, err = io.Copy(file, io.TeeReader(resp.Body, progress))
+	progress.finish()
+	if err != nil {
+		return fmt.Errorf("error downloading %s (run download again to resume): %w", rawURL, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing %s: %w", partPath, err)
+	}
+	return finishDownload(partPath, dest)
+}
+
+func finishDownload(partPath, dest string) error {
+	if err := os.Rename(partPath, dest); err != nil {
+		return fmt.Errorf("error saving %s: %w", dest, err)
+	}
+	fmt.Printf("Saved %s\n", dest)
+	return nil
+}
+
+// progressWriter counts bytes passing through it and redraws a progress
+// line at most a few times a second.
+type progressWriter struct {
+	name     string
+	written  int64
+	total    int64
+	lastDraw time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if time.Since(p.lastDraw) >= 200*time.Millisecond {
+		p.draw()
+	}
+	return len(b), nil
+}
+
+func (p *progressWriter) draw() {
+	p.lastDraw = time.Now()
+	if p.total > 0 {
+		fmt.Printf("\r%s: %s / %s (%d%%)", p.name, formatBytes(p.written), formatBytes(p.total), p.written*100/p.total)
+		return
+	}
+	fmt.Printf("\r%s: %s", p.name, formatBytes(p.written))
+}
+
+func (p *progressWriter) finish() {
+	p.draw()
+	fmt.Println()
+}
+
+// ===== Helper Functions =====
+
+// enclosureFileName picks a local file name for an enclosure: the post id
+// followed by the last segment of its URL or, failing that, by the media
+// type's extension. The id keeps episodes apart on hosts that give every
+// file the same name, such as default_tc.mp3.
+func enclosureFileName(enclosure database.PostEnclosure, post database.Post) string {
+	name := shortID(post.ID)
+	if u, err := url.Parse(enclosure.Url); err == nil {
+		if base := safeFileName(path.Base(u.Path)); base != "" && base != "." && base != ".." && base != "_" {
+			return name + "-" + base
+		}
+	}
+
+	if exts, err := mime.ExtensionsByType(enclosure.Type); err == nil && len(exts) > 0 {
+		name += exts[0]
+	}
+	return name
+}
+
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < 0x20 {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+}
+
+func formatDuration(seconds int) string {
+	d := time.Duration(seconds) * time.Second
+	h, m, sec := int(d.Hours()), int(d.Minutes())%60, seconds%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, sec)
+	}
+	return fmt.Sprintf("%d:%02d", m, sec)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
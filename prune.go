@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/adamararcane/gator/internal/config"
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/adamararcane/gator/internal/htmltext"
+)
+
+func handlerPrune(s *state, cmd command) error {
+	if len(cmd.args) != 0 {
+		return fmt.Errorf("error: no args needed")
+	}
+
+	feeds, err := s.db.ListFeeds(context.Background())
+	if err != nil {
+		return fmt.Errorf("error getting feeds: %w", err)
+	}
+
+	var total int64
+	for _, feed := range feeds {
+		pruned, err := pruneFeed(s, feed)
+		if err != nil {
+			return err
+		}
+		if pruned > 0 {
+			fmt.Printf("* %s: %d posts removed\n", htmltext.Line(feed.Name), pruned)
+		}
+		total += pruned
+	}
+
+	fmt.Printf("Pruned %d posts\n", total)
+	return nil
+}
+
+// pruneFeed deletes the posts of feed that fall outside its retention
+// policy, returning how many were removed. Starred posts and posts a
+// follower of the feed hasn't read are never deleted.
+func pruneFeed(s *state, feed database.Feed) (int64, error) {
+	retention := s.cfg.RetentionFor(feed.Url)
+
+	var pruned int64
+	if retention.Days > 0 {
+		n, err := s.db.PrunePostsOlderThan(context.Background(), database.PrunePostsOlderThanParams{
+			FeedID: feed.ID,
+			Cutoff: time.Now().AddDate(0, 0, -retention.Days),
+		})
+		if err != nil {
+			return pruned, fmt.Errorf("error pruning posts of %s: %w", feed.Name, err)
+		}
+		pruned += n
+	}
+
+	if retention.Count > 0 {
+		n, err := s.db.PrunePostsBeyondNewest(context.Background(), database.PrunePostsBeyondNewestParams{
+			FeedID: feed.ID,
+			Keep:   int32(retention.Count),
+		})
+		if err != nil {
+			return pruned, fmt.Errorf("error pruning posts of %s: %w", feed.Name, err)
+		}
+		pruned += n
+	}
+
+	return pruned, nil
+}
+
+// retentionCutoff returns the publication time before which items of a
+// freshly fetched feed fall outside retention: older than the day limit, or
+// older than the newest Count items in the feed. Such items are skipped at
+// ingest even though no one has read them, unlike stored posts, which
+// pruneFeed keeps until every follower has read them.
+func retentionCutoff(retention config.Retention, items []RSSItem) time.Time {
+	var cutoff time.Time
+	if retention.Days > 0 {
+		cutoff = time.Now().AddDate(0, 0, -retention.Days)
+	}
+
+	if retention.Count > 0 {
+		var published []time.Time
+		for _, item := range items {
+			if t := item.publishedAt(); t.Valid {
+				published = append(published, t.Time)
+			}
+		}
+		if len(published) > retention.Count {
+			slices.SortFunc(published, func(a, b time.Time) int { return b.Compare(a) })
+			if t := published[retention.Count-1]; t.After(cutoff) {
+				cutoff = t
+			}
+		}
+	}
+
+	return cutoff
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+type RSSFeed struct {
+	Channel struct {
+		Title       string    `xml:"title"`
+		Link        string    `xml:"link"`
+		Description string    `xml:"description"`
+		TTL         string    `xml:"ttl"`
+		SkipHours   []int     `xml:"skipHours>hour"`
+		SkipDays    []string  `xml:"skipDays>day"`
+		Item        []RSSItem `xml:"item"`
+	} `xml:"channel"`
+
+	// maxAge is the freshness lifetime the server gave the response in its
+	// Cache-Control header, if any.
+	maxAge time.Duration
+	// movedTo is the url the feed was permanently redirected to, if it was.
+	movedTo string
+	// warning says how the feed was malformed if it had to be parsed
+	// leniently.
+	warning string
+}
+
+type RSSItem struct {
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description"`
+	PubDate     string         `xml:"pubDate"`
+	GUID        string         `xml:"guid"`
+	Author      string         `xml:"author"`
+	Creator     string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string       `xml:"category"`
+	Content     string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Enclosures  []RSSEnclosure `xml:"enclosure"`
+
+	ITunesDuration string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesEpisode  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ITunesSeason   string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season"`
+	ITunesSummary  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	ITunesImage    struct {
+		Href string `xml:"href,attr"`
+	} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+}
+
+// RSSEnclosure is a media file attached to an item. Length is kept as text
+// because publishers routinely leave it empty or fill it with junk.
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// dedupKey identifies an item within its feed: its guid when the publisher
+// provides one, otherwise its link, otherwise a hash of its content.
+func (item RSSItem) dedupKey() string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	if link := strings.TrimSpace(item.Link); link != "" {
+		return link
+	}
+	sum := sha256.Sum256([]byte(item.Title + "\x00" + item.Description + "\x00" + item.Content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// durationSeconds parses itunes:duration, which publishers write either as
+// a number of seconds or as [HH:]MM:SS.
+func (item RSSItem) durationSeconds() sql.NullInt32 {
+	raw := strings.TrimSpace(item.ITunesDuration)
+	if raw == "" {
+		return sql.NullInt32{}
+	}
+
+	seconds := 0
+	for _, part := range strings.Split(raw, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return sql.NullInt32{}
+		}
+		seconds = seconds*60 + n
+	}
+	return sql.NullInt32{Int32: int32(seconds), Valid: true}
+}
+
+func (item RSSItem) publishedAt() sql.NullTime {
+	t, err := time.Parse(time.RFC1123Z, item.PubDate)
+	if err != nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+func parseNullInt32(s string) sql.NullInt32 {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 32)
+	if err != nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(n), Valid: true}
+}
+
+// author returns the item's author, preferring dc:creator since <author> is
+// meant to hold an email address.
+func (item RSSItem) author() string {
+	if creator := strings.TrimSpace(item.Creator); creator != "" {
+		return creator
+	}
+	return strings.TrimSpace(item.Author)
+}
+
+func (f *fetcher) fetchFeed(feedURL string, auth *feedAuth) (*RSSFeed, error) {
+
+	var feed RSSFeed
+
+	req, err := http.NewRequest("GET", feedURL, nil)
+	if err != nil {
+		return &RSSFeed{}, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Add("User-Agent", f.userAgent)
+	if auth != nil {
+		auth.apply(req)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return &RSSFeed{}, fmt.Errorf("error getting rss feed: %w", fetchError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &RSSFeed{}, &httpStatusError{
+			code:       resp.StatusCode,
+			status:     resp.Status,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	feed.maxAge = parseMaxAge(resp.Header.Get("Cache-Control"))
+	feed.movedTo = permanentRedirect(resp)
+
+	body, err := f.readBody(resp)
+	if err != nil {
+		return &RSSFeed{}, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	feed.warning, err = decodeFeed(body, resp.Header.Get("Content-Type"), &feed)
+	if err != nil {
+		return &RSSFeed{}, fmt.Errorf("error unmarshaling xml: %w: %w", errInvalidFeed, err)
+	}
+
+	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
+	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
+
+	for i := range feed.Channel.Item {
+		feed.Channel.Item[i].Title = html.UnescapeString(feed.Channel.Item[i].Title)
+		// Podcast feeds often only describe episodes in itunes:summary.
+		if strings.TrimSpace(feed.Channel.Item[i].Description) == "" {
+			feed.Channel.Item[i].Description = feed.Channel.Item[i].ITunesSummary
+		}
+		feed.Channel.Item[i].Description = html.UnescapeString(feed.Channel.Item[i].Description)
+		for j, category := range feed.Channel.Item[i].Categories {
+			feed.Channel.Item[i].Categories[j] = strings.TrimSpace(html.UnescapeString(category))
+		}
+	}
+
+	return &feed, nil
+
+}
+
+// decodeFeed parses body into feed. A feed that isn't well-formed XML is
+// parsed again leniently, and the returned warning says why.
+func decodeFeed(body []byte, contentType string, feed *RSSFeed) (string, error) {
+	text, transcoded := toUTF8(body, contentType)
+
+	var parsed RSSFeed
+	strictErr := parseXML(text, transcoded, true, &parsed)
+	if strictErr != nil {
+		parsed = RSSFeed{}
+		if err := parseXML(sanitizeXML(text), transcoded, false, &parsed); err != nil {
+			return "", strictErr
+		}
+	}
+
+	feed.Channel = parsed.Channel
+	if strictErr != nil {
+		return fmt.Sprintf("malformed feed, parsed leniently: %v", strictErr), nil
+	}
+	return "", nil
+}
+
+// toUTF8 transcodes body to UTF-8, reporting whether it did. As RFC 7303
+// specifies, a charset in the Content-Type header takes precedence over a
+// byte order mark, which takes precedence over the encoding in the XML
+// declaration. A charset the header names but we don't know is ignored,
+// since servers often get it wrong.
+func toUTF8(body []byte, contentType string) ([]byte, bool) {
+	var labels []string
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		labels = append(labels, params["charset"])
+	}
+	if bytes.HasPrefix(body, []byte{0xFE, 0xFF}) || bytes.HasPrefix(body, []byte{0xFF, 0xFE}) {
+		labels = append(labels, "utf-16")
+	}
+	if m := xmlEncoding.FindSubmatch(body[:min(len(body), 256)]); m != nil {
+		labels = append(labels, string(m[1]))
+	}
+
+	for _, label := range labels {
+		r, err := charset.NewReaderLabel(label, bytes.NewReader(body))
+		if err != nil {
+			continue
+		}
+		text, err := io.ReadAll(r)
+		if err != nil {
+			continue
+		}
+		return text, true
+	}
+	return body, false
+}
+
+var xmlEncoding = regexp.MustCompile(`^\x{FEFF}?\s*<\?xml[^>]*?encoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// parseXML decodes text into feed. Unless text was already transcoded,
+// the decoder converts any encoding named in the XML declaration. A
+// non-strict parse accepts unclosed HTML void elements such as <br> and
+// HTML entities.
+func parseXML(text []byte, transcoded, strict bool, feed *RSSFeed) error {
+	decoder := xml.NewDecoder(bytes.NewReader(text))
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if transcoded {
+			return input, nil
+		}
+		return charset.NewReaderLabel(label, input)
+	}
+	if !strict {
+		decoder.Strict = false
+		decoder.AutoClose = htmlVoidElements
+		decoder.Entity = xml.HTMLEntity
+	}
+	return decoder.Decode(feed)
+}
+
+// sanitizeXML repairs the most common ways feeds break XML: a byte order
+// mark, invalid UTF-8, control characters XML doesn't allow, and
+// ampersands that don't start an entity.
+func sanitizeXML(text []byte) []byte {
+	text = bytes.TrimPrefix(text, []byte("\xEF\xBB\xBF"))
+	text = bytes.ToValidUTF8(text, []byte("\uFFFD"))
+
+	var out bytes.Buffer
+	out.Grow(len(text))
+	for i, r := range string(text) {
+		switch {
+		case r == '&' && !entityRef.Match(text[i:min(len(text), i+40)]):
+			out.WriteString("&amp;")
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r', r == 0xFFFE, r == 0xFFFF:
+			// Dropped
+		default:
+			out.WriteRune(r)
+		}
+	}
+	return out.Bytes()
+}
+
+// htmlVoidElements are the HTML elements that never have a closing tag,
+// except link, which RSS uses for urls.
+var htmlVoidElements = []string{"area", "base", "br", "col", "embed", "hr", "img", "input", "meta", "param", "source", "track", "wbr"}
+
+var entityRef = regexp.MustCompile(`^&(?:#[0-9]+|#[xX][0-9a-fA-F]+|[A-Za-z][A-Za-z0-9._-]*);`)
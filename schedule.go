@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/adamararcane/gator/internal/htmltext"
+)
+
+const (
+	// Feeds without a manual interval are polled at half their average
+	// posting interval, within these bounds.
+	defaultFetchInterval = time.Hour
+	minFetchInterval     = 15 * time.Minute
+	maxFetchInterval     = 24 * time.Hour
+
+	// adaptiveSampleSize is how many recent posts the posting frequency is
+	// measured over.
+	adaptiveSampleSize = 10
+
+	// maxFetchDelay caps how far a fetch is ever pushed back, whatever a
+	// publisher or user asks for, so delays fit the int32 seconds columns.
+	maxFetchDelay = 30 * 24 * time.Hour
+)
+
+func handlerInterval(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 || len(cmd.args) > 2 {
+		return fmt.Errorf("error: usage: interval <url> [<duration>|auto]")
+	}
+
+	feed, err := s.db.GetFeed(context.Background(), cmd.args[0])
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("error: no feed with url '%s'", cmd.args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("error getting feed: %w", err)
+	}
+
+	// Step 1: Without a new setting, show the current schedule
+	if len(cmd.args) == 1 {
+		if feed.DeadAt.Valid {
+			fmt.Printf("%s has been gone since %s and is no longer fetched; set an interval to fetch it again\n", htmltext.Line(feed.Name), feed.DeadAt.Time.Local().Format("Mon Jan 2 15:04"))
+			return nil
+		}
+		interval, err := fetchInterval(s, feed)
+		if err != nil {
+			return err
+		}
+		mode := "adaptive"
+		if feed.FetchIntervalSeconds.Valid {
+			mode = "manual"
+		}
+		fmt.Printf("%s is fetched every %s (%s)\n", htmltext.Line(feed.Name), interval, mode)
+		if feed.NextFetchAt.Valid {
+			fmt.Printf("Next fetch: %s\n", feed.NextFetchAt.Time.Local().Format("Mon Jan 2 15:04"))
+		}
+		return nil
+	}
+
+	// Step 2: Only the feed's creator or an admin can change its schedule
+	if feed.UserID != user.ID && !user.IsAdmin {
+		return fmt.Errorf("error: only the user who added '%s' or an admin can change its interval", feed.Name)
+	}
+
+	var seconds sql.NullInt32
+	if cmd.args[1] != "auto" {
+		interval, err := time.ParseDuration(cmd.args[1])
+		if err != nil {
+			return fmt.Errorf("error parsing interval (ex. 30m, 6h or auto): %w", err)
+		}
+		if interval < time.Minute {
+			return fmt.Errorf("error: interval must be at least a minute")
+		}
+		if interval > maxFetchDelay {
+			return fmt.Errorf("error: interval must be at most %s", maxFetchDelay)
+		}
+		seconds = sql.NullInt32{Int32: int32(interval.Seconds()), Valid: true}
+	}
+
+	err = s.db.SetFeedFetchInterval(context.Background(), database.SetFeedFetchIntervalParams{
+		ID:                   feed.ID,
+		FetchIntervalSeconds: seconds,
+	})
+	if err != nil {
+		return fmt.Errorf("error setting interval: %w", err)
+	}
+
+	if feed.DeadAt.Valid {
+		fmt.Printf("%s is no longer marked as gone\n", htmltext.Line(feed.Name))
+	}
+	if seconds.Valid {
+		fmt.Printf("%s will be fetched every %s\n", htmltext.Line(feed.Name), time.Duration(seconds.Int32)*time.Second)
+	} else {
+		fmt.Printf("%s will be fetched as often as it posts\n", htmltext.Line(feed.Name))
+	}
+	return nil
+}
+
+// fetchInterval returns how long to wait between fetches of feed: its
+// manual interval if one is set, otherwise one adapted to how often it
+// posts.
+func fetchInterval(s *state, feed database.Feed) (time.Duration, error) {
+	if feed.FetchIntervalSeconds.Valid {
+		return time.Duration(feed.FetchIntervalSeconds.Int32) * time.Second, nil
+	}
+
+	published, err := s.db.GetRecentPostTimes(context.Background(), database.GetRecentPostTimesParams{
+		FeedID: feed.ID,
+		Limit:  adaptiveSampleSize,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error getting post times: %w", err)
+	}
+
+	var times []time.Time
+	for _, t := range published {
+		if t.Valid {
+			times = append(times, t.Time)
+		}
+	}
+	return adaptiveInterval(times), nil
+}
+
+// adaptiveInterval polls at half the average gap between the given post
+// times (newest first), so a new post waits half a posting interval on
+// average before it is picked up.
+func adaptiveInterval(times []time.Time) time.Duration {
+	if len(times) < 2 {
+		return defaultFetchInterval
+	}
+
+	span := times[0].Sub(times[len(times)-1])
+	interval := span / time.Duration(len(times)-1) / 2
+	return min(max(interval, minFetchInterval), maxFetchInterval).Round(time.Minute)
+}
+
+// politeDelay stretches interval so the next fetch respects what the
+// publisher asked for: the channel's ttl (in minutes), the response's
+// Cache-Control max-age, and the hours (GMT) and days the channel asks not
+// to be polled in.
+func politeDelay(interval time.Duration, feed *RSSFeed, now time.Time) time.Duration {
+	delay := max(interval, feed.maxAge)
+	if ttl, err := strconv.Atoi(strings.TrimSpace(feed.Channel.TTL)); err == nil && ttl > 0 {
+		delay = max(delay, time.Duration(min(ttl, int(maxFetchDelay/time.Minute)))*time.Minute)
+	}
+	delay = min(delay, maxFetchDelay)
+
+	// Move to the start of the next hour until one isn't skipped. A week of
+	// hours covers every combination of skipped hours and days.
+	next := now.Add(delay).UTC()
+	for range 7 * 24 {
+		if !skipped(feed, next) {
+			break
+		}
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	// Round up to the minute so the database clock, which only keeps whole
+	// seconds, can't land the fetch back inside a skipped hour.
+	return (next.Sub(now) + time.Minute - 1).Truncate(time.Minute)
+}
+
+// skipped reports whether t (in UTC) falls in the channel's skipHours or
+// skipDays.
+func skipped(feed *RSSFeed, t time.Time) bool {
+	for _, hour := range feed.Channel.SkipHours {
+		// Hour 24 is sometimes used for midnight.
+		if hour%24 == t.Hour() {
+			return true
+		}
+	}
+	for _, day := range feed.Channel.SkipDays {
+		if strings.EqualFold(strings.TrimSpace(day), t.Weekday().String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// deferFetch pushes the next fetch of feed back to delay from now.
+func deferFetch(s *state, feed database.Feed, delay time.Duration) error {
+	err := s.db.DeferFeedFetch(context.Background(), database.DeferFeedFetchParams{
+		ID:           feed.ID,
+		DelaySeconds: int32(min(delay, maxFetchDelay).Seconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("error scheduling next fetch: %w", err)
+	}
+	return nil
+}
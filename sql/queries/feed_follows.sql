@@ -13,10 +13,19 @@ JOIN users u ON u.id = iff.user_id
 JOIN feeds f ON f.id = iff.feed_id;
 
 -- name: GetFeedFollowsForUser :many
-SELECT f.name, f.url
+SELECT f.id, f.name, f.url
 FROM feed_follows ff
 JOIN feeds f ON ff.feed_id = f.id
-WHERE ff.user_id = $1;
+WHERE ff.user_id = $1
+ORDER BY f.name;
 
 -- name: UnfollowFeed :exec
-DELETE FROM feed_follows WHERE user_id = $1 AND feed_id = $2;
\ No newline at end of file
+DELETE FROM feed_follows WHERE user_id = $1 AND feed_id = $2;
+
+-- name: ListFeedFollows :many
+SELECT * FROM feed_follows ORDER BY created_at;
+
+-- name: RestoreFeedFollow :exec
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING;
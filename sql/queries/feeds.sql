@@ -11,17 +11,104 @@ VALUES (
 RETURNING *;
 
 -- name: GetFeeds :many
-SELECT name, url, user_id FROM feeds;
+SELECT name, url, user_id, dead_at, parse_warning FROM feeds;
 
 -- name: GetFeed :one
 SELECT * FROM feeds WHERE url = $1 LIMIT 1;
 
+-- name: GetFeedByID :one
+SELECT * FROM feeds WHERE id = $1;
+
 -- name: MarkFeedFetched :exec
 UPDATE feeds
-SET updated_at = NOW(), last_fetched_at = NOW()
-WHERE id = $1;
+SET updated_at = NOW(),
+    last_fetched_at = NOW(),
+    next_fetch_at = NOW() + sqlc.arg(interval_seconds)::int * INTERVAL '1 second'
+WHERE id = sqlc.arg(id);
+
+-- name: DeferFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = NOW() + sqlc.arg(delay_seconds)::int * INTERVAL '1 second'
+WHERE id = sqlc.arg(id);
 
 -- name: GetNextFeedToFetch :one
-SELECT * FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
+-- due_seconds is how long the feed has been due. It is worked out here
+-- because next_fetch_at holds the server's local time, written with NOW().
+SELECT sqlc.embed(feeds),
+    EXTRACT(EPOCH FROM NOW() - COALESCE(next_fetch_at, updated_at))::float8 AS due_seconds
+FROM feeds
+WHERE dead_at IS NULL AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
 LIMIT 1;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1;
+
+-- name: CountFeedsForUser :one
+SELECT COUNT(*) FROM feeds WHERE user_id = $1;
+
+-- name: ListFeeds :many
+SELECT * FROM feeds ORDER BY created_at;
+
+-- name: DeleteAllFeeds :exec
+DELETE FROM feeds;
+
+-- name: RestoreFeed :execrows
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_interval_seconds, next_fetch_at, dead_at, parse_warning, auth)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT DO NOTHING;
+
+-- name: SetFeedFetchInterval :exec
+UPDATE feeds
+SET fetch_interval_seconds = $2, next_fetch_at = NULL, dead_at = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetRecentPostTimes :many
+SELECT published_at FROM posts
+WHERE feed_id = $1 AND published_at IS NOT NULL
+ORDER BY published_at DESC
+LIMIT $2;
+
+-- name: SetFeedUrl :exec
+UPDATE feeds SET url = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: MarkFeedDead :exec
+UPDATE feeds SET dead_at = NOW(), updated_at = NOW() WHERE id = $1;
+
+-- name: ReviveFeed :exec
+UPDATE feeds SET dead_at = NULL, next_fetch_at = NULL, updated_at = NOW() WHERE id = $1;
+
+-- name: SetFeedParseWarning :exec
+UPDATE feeds SET parse_warning = $2 WHERE id = $1;
+
+-- name: SetFeedAuth :exec
+UPDATE feeds SET auth = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE feed_follows.feed_id = sqlc.arg(from_feed_id)
+  AND feed_follows.user_id NOT IN (
+      SELECT other.user_id FROM feed_follows AS other WHERE other.feed_id = sqlc.arg(to_feed_id)
+  );
+
+-- name: MoveFeedPostStates :exec
+-- Carries read and starred marks over from posts that stay behind because
+-- the target feed already has a post with the same guid.
+INSERT INTO post_states (user_id, post_id, read_at, starred_at)
+SELECT post_states.user_id, target.id, post_states.read_at, post_states.starred_at
+FROM post_states
+JOIN posts AS source ON source.id = post_states.post_id
+JOIN posts AS target ON target.guid = source.guid AND target.feed_id = sqlc.arg(to_feed_id)
+WHERE source.feed_id = sqlc.arg(from_feed_id)
+ON CONFLICT (user_id, post_id) DO UPDATE SET
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    starred_at = COALESCE(post_states.starred_at, EXCLUDED.starred_at);
+
+-- name: MoveFeedPosts :exec
+UPDATE posts
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE posts.feed_id = sqlc.arg(from_feed_id)
+  AND posts.guid NOT IN (
+      SELECT other.guid FROM posts AS other WHERE other.feed_id = sqlc.arg(to_feed_id)
+  );
@@ -0,0 +1,27 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_states (user_id, post_id, read_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id, post_id) DO UPDATE SET read_at = NOW();
+
+-- name: MarkPostUnread :exec
+UPDATE post_states SET read_at = NULL
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: MarkPostStarred :exec
+INSERT INTO post_states (user_id, post_id, starred_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id, post_id) DO UPDATE SET starred_at = NOW();
+
+-- name: MarkPostUnstarred :exec
+UPDATE post_states SET starred_at = NULL
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: ListPostStates :many
+SELECT * FROM post_states;
+
+-- name: RestorePostState :exec
+INSERT INTO post_states (user_id, post_id, read_at, starred_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    starred_at = COALESCE(post_states.starred_at, EXCLUDED.starred_at);
@@ -1,5 +1,5 @@
--- name: CreatePost :one
-INSERT INTO posts (id,  title, url, description, published_at, feed_id, created_at, updated_at)
+-- name: UpsertPost :one
+INSERT INTO posts (id,  title, url, description, published_at, feed_id, guid, author, content_encoded, duration_seconds, episode, season, image_url, created_at, updated_at)
 VALUES (
     $1,
     $2,
@@ -7,16 +7,177 @@ VALUES (
     $4,
     $5,
     $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
     NOW(),
     NOW()
 )
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    published_at = EXCLUDED.published_at,
+    author = EXCLUDED.author,
+    content_encoded = EXCLUDED.content_encoded,
+    duration_seconds = EXCLUDED.duration_seconds,
+    episode = EXCLUDED.episode,
+    season = EXCLUDED.season,
+    image_url = EXCLUDED.image_url,
+    content = CASE WHEN posts.url = EXCLUDED.url THEN posts.content END,
+    updated_at = NOW()
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+    OR posts.url IS DISTINCT FROM EXCLUDED.url
+    OR posts.description IS DISTINCT FROM EXCLUDED.description
+    OR posts.content_encoded IS DISTINCT FROM EXCLUDED.content_encoded
 RETURNING *;
 
+-- name: RekeyLegacyPost :exec
+-- Posts stored before guids were tracked were keyed by their url. Such a
+-- post takes on its item's real guid the next time the item is fetched, so
+-- the upsert updates it instead of adding a duplicate.
+UPDATE posts SET guid = sqlc.arg(guid)
+WHERE posts.feed_id = sqlc.arg(feed_id)
+  AND posts.guid = sqlc.arg(url)
+  AND posts.url = sqlc.arg(url)
+  AND sqlc.arg(guid) NOT IN (
+      SELECT other.guid FROM posts AS other WHERE other.feed_id = sqlc.arg(feed_id)
+  );
+
 -- name: GetPostsForUser :many
-SELECT posts.*, feeds.name AS feed_name FROM posts
+SELECT posts.*, feeds.name AS feed_name, post_states.read_at, post_states.starred_at FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+ORDER BY posts.published_at DESC
+LIMIT $2;
+
+-- name: GetPostsForUserFeed :many
+SELECT posts.*, feeds.name AS feed_name, post_states.read_at, post_states.starred_at FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.feed_id = $2
+ORDER BY posts.published_at DESC
+LIMIT $3;
+
+-- name: GetPostsByIDPrefix :many
+SELECT posts.* FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND posts.id::text LIKE sqlc.arg(prefix)::text || '%'
+LIMIT 2;
+
+-- name: UpdatePostContent :exec
+UPDATE posts
+SET content = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: AddPostCategory :exec
+INSERT INTO post_categories (post_id, name)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePostCategories :exec
+DELETE FROM post_categories WHERE post_id = $1;
+
+-- name: GetPostCategories :many
+SELECT name FROM post_categories
+WHERE post_id = $1
+ORDER BY name;
+
+-- name: AddPostEnclosure :exec
+INSERT INTO post_enclosures (post_id, url, type, length)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePostEnclosures :exec
+DELETE FROM post_enclosures WHERE post_id = $1;
+
+-- name: GetPostEnclosures :many
+SELECT * FROM post_enclosures
+WHERE post_id = $1;
+
+-- name: GetEpisodesForUser :many
+SELECT posts.id, posts.title, posts.published_at, posts.duration_seconds, posts.episode, posts.season,
+    feeds.name AS feed_name, post_enclosures.url AS enclosure_url, post_enclosures.type AS enclosure_type,
+    post_enclosures.length AS enclosure_length
+FROM posts
+-- A post is listed once, with its first enclosure, however many it has.
+JOIN post_enclosures ON post_enclosures.post_id = posts.id
+    AND post_enclosures.url = (SELECT MIN(e.url) FROM post_enclosures e WHERE e.post_id = posts.id)
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 JOIN feeds ON posts.feed_id = feeds.id
 WHERE feed_follows.user_id = $1
 ORDER BY posts.published_at DESC
 LIMIT $2;
---
\ No newline at end of file
+
+-- name: ListPosts :many
+SELECT * FROM posts ORDER BY created_at;
+
+-- name: ListPostCategories :many
+SELECT * FROM post_categories ORDER BY post_id, name;
+
+-- name: ListPostEnclosures :many
+SELECT * FROM post_enclosures ORDER BY post_id, url;
+
+-- name: DeleteAllPosts :exec
+DELETE FROM posts;
+
+-- name: GetPostByGuid :one
+SELECT * FROM posts WHERE feed_id = $1 AND guid = $2;
+
+-- name: RestorePost :execrows
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content, guid, author, content_encoded, duration_seconds, episode, season, image_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+ON CONFLICT DO NOTHING;
+
+-- name: PrunePostsOlderThan :execrows
+DELETE FROM posts
+WHERE posts.feed_id = sqlc.arg(feed_id)
+    AND COALESCE(posts.published_at, posts.created_at) < sqlc.arg(cutoff)::timestamp
+    -- Starred posts and posts a follower hasn't read yet are always kept.
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.starred_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id
+            AND NOT EXISTS (
+                SELECT 1 FROM post_states
+                WHERE post_states.post_id = posts.id
+                    AND post_states.user_id = feed_follows.user_id
+                    AND post_states.read_at IS NOT NULL
+            )
+    );
+
+-- name: PrunePostsBeyondNewest :execrows
+DELETE FROM posts
+WHERE posts.feed_id = sqlc.arg(feed_id)
+    AND posts.id NOT IN (
+        SELECT newest.id FROM posts newest
+        WHERE newest.feed_id = sqlc.arg(feed_id)
+        ORDER BY COALESCE(newest.published_at, newest.created_at) DESC
+        LIMIT sqlc.arg(keep)::int
+    )
+    -- Starred posts and posts a follower hasn't read yet are always kept.
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.starred_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id
+            AND NOT EXISTS (
+                SELECT 1 FROM post_states
+                WHERE post_states.post_id = posts.id
+                    AND post_states.user_id = feed_follows.user_id
+                    AND post_states.read_at IS NOT NULL
+            )
+    );
@@ -1,10 +1,12 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+-- The first user to register becomes the admin.
+INSERT INTO users (id, created_at, updated_at, name, is_admin)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    NOT EXISTS (SELECT 1 FROM users)
 )
 RETURNING *;
 
@@ -18,4 +20,21 @@ DELETE FROM users;
 SELECT name FROM users;
 
 -- name: GetUserName :one
-SELECT name FROM users WHERE id = $1 LIMIT 1;
\ No newline at end of file
+SELECT name FROM users WHERE id = $1 LIMIT 1;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1;
+
+-- name: CountAdmins :one
+SELECT COUNT(*) FROM users WHERE is_admin;
+
+-- name: PromoteUser :exec
+UPDATE users SET is_admin = TRUE, updated_at = NOW() WHERE id = $1;
+
+-- name: ListUsers :many
+SELECT * FROM users ORDER BY created_at;
+
+-- name: RestoreUser :execrows
+INSERT INTO users (id, created_at, updated_at, name, is_admin)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE posts
+ADD guid TEXT NULL,
+ADD author TEXT NULL,
+ADD content_encoded TEXT NULL;
+
+CREATE TABLE post_categories (
+    post_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (post_id, name),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE post_enclosures (
+    post_id UUID NOT NULL,
+    url TEXT NOT NULL,
+    type TEXT NOT NULL,
+    length BIGINT NULL,
+    PRIMARY KEY (post_id, url),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE post_enclosures;
+DROP TABLE post_categories;
+
+ALTER TABLE posts
+DROP COLUMN content_encoded,
+DROP COLUMN author,
+DROP COLUMN guid;
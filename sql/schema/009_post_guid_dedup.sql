@@ -0,0 +1,25 @@
+-- +goose Up
+UPDATE posts SET guid = url WHERE guid IS NULL OR guid = '';
+
+ALTER TABLE posts
+ALTER COLUMN guid SET NOT NULL,
+DROP CONSTRAINT posts_url_key,
+ADD CONSTRAINT posts_feed_id_guid_unique UNIQUE (feed_id, guid);
+
+-- +goose Down
+-- Items with their own guid may share a url, which url can't be unique
+-- with. Keep the oldest post for each url and delete the rest, along with
+-- their read state and metadata.
+DELETE FROM posts
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY url ORDER BY created_at, id) AS n
+        FROM posts
+    ) ranked
+    WHERE n > 1
+);
+
+ALTER TABLE posts
+DROP CONSTRAINT posts_feed_id_guid_unique,
+ADD CONSTRAINT posts_url_key UNIQUE (url),
+ALTER COLUMN guid DROP NOT NULL;
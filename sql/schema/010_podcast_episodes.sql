@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE posts
+ADD duration_seconds INTEGER NULL,
+ADD episode INTEGER NULL,
+ADD season INTEGER NULL,
+ADD image_url TEXT NULL;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN image_url,
+DROP COLUMN season,
+DROP COLUMN episode,
+DROP COLUMN duration_seconds;
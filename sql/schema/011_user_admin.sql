@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE users
+ADD is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The oldest account becomes the admin of an existing install.
+UPDATE users SET is_admin = TRUE
+WHERE id = (SELECT id FROM users ORDER BY created_at LIMIT 1);
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN is_admin;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE post_states
+ADD starred_at TIMESTAMP NULL;
+
+-- +goose Down
+ALTER TABLE post_states
+DROP COLUMN starred_at;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD fetch_interval_seconds INTEGER NULL,
+ADD next_fetch_at TIMESTAMP NULL;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN next_fetch_at,
+DROP COLUMN fetch_interval_seconds;
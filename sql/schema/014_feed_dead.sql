@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ADD dead_at TIMESTAMP NULL;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN dead_at;
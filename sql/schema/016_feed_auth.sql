@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ADD auth BYTEA NULL;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN auth;
@@ -0,0 +1,33 @@
+-- SQLite can't INSERT inside a CTE, so the store runs these two in turn.
+
+-- name: CreateFeedFollow :exec
+INSERT INTO feed_follows (id, user_id, feed_id, created_at, updated_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+
+-- name: GetFeedFollow :one
+SELECT
+    ff.*,
+    u.name AS user_name,
+    f.name AS feed_name
+FROM feed_follows ff
+JOIN users u ON u.id = ff.user_id
+JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.id = ?;
+
+-- name: GetFeedFollowsForUser :many
+SELECT f.id, f.name, f.url
+FROM feed_follows ff
+JOIN feeds f ON ff.feed_id = f.id
+WHERE ff.user_id = ?
+ORDER BY f.name;
+
+-- name: UnfollowFeed :exec
+DELETE FROM feed_follows WHERE user_id = ? AND feed_id = ?;
+
+-- name: ListFeedFollows :many
+SELECT * FROM feed_follows ORDER BY created_at;
+
+-- name: RestoreFeedFollow :exec
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,112 @@
+-- name: CreateFeed :one
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?
+)
+RETURNING *;
+
+-- name: GetFeeds :many
+SELECT name, url, user_id, dead_at, parse_warning FROM feeds;
+
+-- name: GetFeed :one
+SELECT * FROM feeds WHERE url = ? LIMIT 1;
+
+-- name: GetFeedByID :one
+SELECT * FROM feeds WHERE id = ?;
+
+-- name: MarkFeedFetched :exec
+UPDATE feeds
+SET updated_at = CURRENT_TIMESTAMP,
+    last_fetched_at = CURRENT_TIMESTAMP,
+    next_fetch_at = datetime('now', '+' || CAST(sqlc.arg(interval_seconds) AS INTEGER) || ' seconds')
+WHERE id = sqlc.arg(id);
+
+-- name: DeferFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = datetime('now', '+' || CAST(sqlc.arg(delay_seconds) AS INTEGER) || ' seconds')
+WHERE id = sqlc.arg(id);
+
+-- name: GetNextFeedToFetch :one
+SELECT sqlc.embed(feeds),
+    CAST((julianday('now') - julianday(COALESCE(next_fetch_at, updated_at))) * 86400 AS REAL) AS due_seconds
+FROM feeds
+WHERE dead_at IS NULL AND (next_fetch_at IS NULL OR next_fetch_at <= CURRENT_TIMESTAMP)
+ORDER BY next_fetch_at ASC, last_fetched_at ASC
+LIMIT 1;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = ?;
+
+-- name: CountFeedsForUser :one
+SELECT COUNT(*) FROM feeds WHERE user_id = ?;
+
+-- name: ListFeeds :many
+SELECT * FROM feeds ORDER BY created_at;
+
+-- name: DeleteAllFeeds :exec
+DELETE FROM feeds;
+
+-- name: RestoreFeed :execrows
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_interval_seconds, next_fetch_at, dead_at, parse_warning, auth)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: SetFeedFetchInterval :exec
+UPDATE feeds
+SET fetch_interval_seconds = ?, next_fetch_at = NULL, dead_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: GetRecentPostTimes :many
+SELECT published_at FROM posts
+WHERE feed_id = ? AND published_at IS NOT NULL
+ORDER BY published_at DESC
+LIMIT ?;
+
+-- name: SetFeedUrl :exec
+UPDATE feeds SET url = sqlc.arg(url), updated_at = CURRENT_TIMESTAMP WHERE id = sqlc.arg(id);
+
+-- name: MarkFeedDead :exec
+UPDATE feeds SET dead_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: ReviveFeed :exec
+UPDATE feeds SET dead_at = NULL, next_fetch_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: SetFeedParseWarning :exec
+UPDATE feeds SET parse_warning = sqlc.arg(parse_warning) WHERE id = sqlc.arg(id);
+
+-- name: SetFeedAuth :exec
+UPDATE feeds SET auth = sqlc.arg(auth), updated_at = CURRENT_TIMESTAMP WHERE id = sqlc.arg(id);
+
+-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE feed_follows.feed_id = sqlc.arg(from_feed_id)
+  AND feed_follows.user_id NOT IN (
+      SELECT other.user_id FROM feed_follows AS other WHERE other.feed_id = sqlc.arg(to_feed_id)
+  );
+
+-- name: MoveFeedPostStates :exec
+-- Carries read and starred marks over from posts that stay behind because
+-- the target feed already has a post with the same guid.
+INSERT INTO post_states (user_id, post_id, read_at, starred_at)
+SELECT post_states.user_id, target.id, post_states.read_at, post_states.starred_at
+FROM post_states
+JOIN posts AS source ON source.id = post_states.post_id
+JOIN posts AS target ON target.guid = source.guid AND target.feed_id = sqlc.arg(to_feed_id)
+WHERE source.feed_id = sqlc.arg(from_feed_id)
+ON CONFLICT (user_id, post_id) DO UPDATE SET
+    read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    starred_at = COALESCE(post_states.starred_at, EXCLUDED.starred_at);
+
+-- name: MoveFeedPosts :exec
+UPDATE posts
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE posts.feed_id = sqlc.arg(from_feed_id)
+  AND posts.guid NOT IN (
+      SELECT other.guid FROM posts AS other WHERE other.feed_id = sqlc.arg(to_feed_id)
+  );
@@ -0,0 +1,27 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_states (user_id, post_id, read_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id, post_id) DO UPDATE SET read_at = CURRENT_TIMESTAMP;
+
+-- name: MarkPostUnread :exec
+UPDATE post_states SET read_at = NULL
+WHERE user_id = ? AND post_id = ?;
+
+-- name: MarkPostStarred :exec
+INSERT INTO post_states (user_id, post_id, starred_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id, post_id) DO UPDATE SET starred_at = CURRENT_TIMESTAMP;
+
+-- name: MarkPostUnstarred :exec
+UPDATE post_states SET starred_at = NULL
+WHERE user_id = ? AND post_id = ?;
+
+-- name: ListPostStates :many
+SELECT * FROM post_states;
+
+-- name: RestorePostState :exec
+INSERT INTO post_states (user_id, post_id, read_at, starred_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read_at = COALESCE(post_states.read_at, EXCLUDED.read_at),
+    starred_at = COALESCE(post_states.starred_at, EXCLUDED.starred_at);
@@ -0,0 +1,182 @@
+-- name: UpsertPost :one
+INSERT INTO posts (id,  title, url, description, published_at, feed_id, guid, author, content_encoded, duration_seconds, episode, season, image_url, created_at, updated_at)
+VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    CURRENT_TIMESTAMP,
+    CURRENT_TIMESTAMP
+)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    published_at = EXCLUDED.published_at,
+    author = EXCLUDED.author,
+    content_encoded = EXCLUDED.content_encoded,
+    duration_seconds = EXCLUDED.duration_seconds,
+    episode = EXCLUDED.episode,
+    season = EXCLUDED.season,
+    image_url = EXCLUDED.image_url,
+    content = CASE WHEN posts.url = EXCLUDED.url THEN posts.content END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE posts.title IS NOT EXCLUDED.title
+    OR posts.url IS NOT EXCLUDED.url
+    OR posts.description IS NOT EXCLUDED.description
+    OR posts.content_encoded IS NOT EXCLUDED.content_encoded
+RETURNING *;
+
+-- name: RekeyLegacyPost :exec
+-- Posts stored before guids were tracked were keyed by their url. Such a
+-- post takes on its item's real guid the next time the item is fetched, so
+-- the upsert updates it instead of adding a duplicate. OR IGNORE leaves it
+-- alone when the feed already has a post with that guid.
+UPDATE OR IGNORE posts SET guid = sqlc.arg(guid)
+WHERE posts.feed_id = sqlc.arg(feed_id)
+  AND posts.guid = sqlc.arg(url)
+  AND posts.url = sqlc.arg(url);
+
+-- name: GetPostsForUser :many
+SELECT posts.*, feeds.name AS feed_name, post_states.read_at, post_states.starred_at FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = ?
+ORDER BY posts.published_at DESC
+LIMIT ?;
+
+-- name: GetPostsForUserFeed :many
+SELECT posts.*, feeds.name AS feed_name, post_states.read_at, post_states.starred_at FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = ? AND posts.feed_id = ?
+ORDER BY posts.published_at DESC
+LIMIT ?;
+
+-- name: GetPostsByIDPrefix :many
+SELECT posts.* FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND posts.id LIKE CAST(sqlc.arg(prefix) AS TEXT) || '%'
+LIMIT 2;
+
+-- name: UpdatePostContent :exec
+UPDATE posts
+SET content = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: AddPostCategory :exec
+INSERT INTO post_categories (post_id, name)
+VALUES (?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePostCategories :exec
+DELETE FROM post_categories WHERE post_id = ?;
+
+-- name: GetPostCategories :many
+SELECT name FROM post_categories
+WHERE post_id = ?
+ORDER BY name;
+
+-- name: AddPostEnclosure :exec
+INSERT INTO post_enclosures (post_id, url, type, length)
+VALUES (?, ?, ?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePostEnclosures :exec
+DELETE FROM post_enclosures WHERE post_id = ?;
+
+-- name: GetPostEnclosures :many
+SELECT * FROM post_enclosures
+WHERE post_id = ?;
+
+-- name: GetEpisodesForUser :many
+SELECT posts.id, posts.title, posts.published_at, posts.duration_seconds, posts.episode, posts.season,
+    feeds.name AS feed_name, post_enclosures.url AS enclosure_url, post_enclosures.type AS enclosure_type,
+    post_enclosures.length AS enclosure_length
+FROM posts
+-- A post is listed once, with its first enclosure, however many it has.
+JOIN post_enclosures ON post_enclosures.post_id = posts.id
+    AND post_enclosures.url = (SELECT MIN(e.url) FROM post_enclosures e WHERE e.post_id = posts.id)
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+WHERE feed_follows.user_id = ?
+ORDER BY posts.published_at DESC
+LIMIT ?;
+
+-- name: ListPosts :many
+SELECT * FROM posts ORDER BY created_at;
+
+-- name: ListPostCategories :many
+SELECT * FROM post_categories ORDER BY post_id, name;
+
+-- name: ListPostEnclosures :many
+SELECT * FROM post_enclosures ORDER BY post_id, url;
+
+-- name: DeleteAllPosts :exec
+DELETE FROM posts;
+
+-- name: GetPostByGuid :one
+SELECT * FROM posts WHERE feed_id = ? AND guid = ?;
+
+-- name: RestorePost :execrows
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content, guid, author, content_encoded, duration_seconds, episode, season, image_url)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: PrunePostsOlderThan :execrows
+DELETE FROM posts
+WHERE posts.feed_id = sqlc.arg(feed_id)
+    AND (posts.published_at < sqlc.arg(cutoff)
+        OR (posts.published_at IS NULL AND posts.created_at < sqlc.arg(cutoff)))
+    -- Starred posts and posts a follower hasn't read yet are always kept.
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.starred_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id
+            AND NOT EXISTS (
+                SELECT 1 FROM post_states
+                WHERE post_states.post_id = posts.id
+                    AND post_states.user_id = feed_follows.user_id
+                    AND post_states.read_at IS NOT NULL
+            )
+    );
+
+-- name: PrunePostsBeyondNewest :execrows
+DELETE FROM posts
+WHERE posts.feed_id = sqlc.arg(feed_id)
+    AND posts.id NOT IN (
+        SELECT newest.id FROM posts newest
+        WHERE newest.feed_id = sqlc.arg(feed_id)
+        ORDER BY COALESCE(newest.published_at, newest.created_at) DESC
+        LIMIT sqlc.arg(keep)
+    )
+    -- Starred posts and posts a follower hasn't read yet are always kept.
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.starred_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id
+            AND NOT EXISTS (
+                SELECT 1 FROM post_states
+                WHERE post_states.post_id = posts.id
+                    AND post_states.user_id = feed_follows.user_id
+                    AND post_states.read_at IS NOT NULL
+            )
+    );
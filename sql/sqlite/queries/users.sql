@@ -0,0 +1,40 @@
+-- name: CreateUser :one
+-- The first user to register becomes the admin.
+INSERT INTO users (id, created_at, updated_at, name, is_admin)
+VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    NOT EXISTS (SELECT 1 FROM users)
+)
+RETURNING *;
+
+-- name: GetUser :one
+SELECT * FROM users WHERE name = ? LIMIT 1;
+
+-- name: ResetDatabase :exec
+DELETE FROM users;
+
+-- name: GetUsers :many
+SELECT name FROM users;
+
+-- name: GetUserName :one
+SELECT name FROM users WHERE id = ? LIMIT 1;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = ?;
+
+-- name: CountAdmins :one
+SELECT COUNT(*) FROM users WHERE is_admin;
+
+-- name: PromoteUser :exec
+UPDATE users SET is_admin = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: ListUsers :many
+SELECT * FROM users ORDER BY created_at;
+
+-- name: RestoreUser :execrows
+INSERT INTO users (id, created_at, updated_at, name, is_admin)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE post_states ADD starred_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE post_states DROP COLUMN starred_at;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds ADD fetch_interval_seconds INT4;
+ALTER TABLE feeds ADD next_fetch_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN next_fetch_at;
+ALTER TABLE feeds DROP COLUMN fetch_interval_seconds;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ADD parse_warning TEXT;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN parse_warning;
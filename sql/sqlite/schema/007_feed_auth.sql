@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ADD auth BLOB;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN auth;
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adamararcane/gator/internal/database"
+	"github.com/adamararcane/gator/internal/htmltext"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/google/uuid"
+)
+
+const tuiPostLimit = 200
+
+func handlerTUI(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return fmt.Errorf("error: no args needed")
+	}
+
+	p := tea.NewProgram(newTUIModel(s, user), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("error running tui: %w", err)
+	}
+
+	return nil
+}
+
+type tuiPane int
+
+const (
+	paneFeeds tuiPane = iota
+	panePosts
+	panePreview
+)
+
+type tuiFeed struct {
+	id   uuid.UUID
+	name string
+	all  bool
+}
+
+type tuiModel struct {
+	s    *state
+	user database.User
+
+	feeds []tuiFeed
+	posts []database.GetPostsForUserRow
+
+	focus         tuiPane
+	feedCursor    int
+	postCursor    int
+	previewOffset int
+
+	width  int
+	height int
+	status string
+}
+
+type feedsLoadedMsg struct{ feeds []tuiFeed }
+
+type postsLoadedMsg struct {
+	feedIndex int
+	posts     []database.GetPostsForUserRow
+}
+
+type postStateMsg struct {
+	postID uuid.UUID
+	readAt sql.NullTime
+}
+
+type statusMsg string
+
+type errMsg struct{ err error }
+
+func newTUIModel(s *state, user database.User) tuiModel {
+	return tuiModel{s: s, user: user, status: "Loading feeds..."}
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return m.loadFeeds()
+}
+
+func (m tuiModel) loadFeeds() tea.Cmd {
+	return func() tea.Msg {
+		follows, err := m.s.db.GetFeedFollowsForUser(context.Background(), m.user.ID)
+		if err != nil {
+			return errMsg{fmt.Errorf("error getting followed feeds: %w", err)}
+		}
+
+		feeds := []tuiFeed{{name: "All feeds", all: true}}
+		for _, follow := range follows {
+			feeds = append(feeds, tuiFeed{id: follow.ID, name: htmltext.Line(follow.Name)})
+		}
+		return feedsLoadedMsg{feeds: feeds}
+	}
+}
+
+func (m tuiModel) loadPosts(feedIndex int) tea.Cmd {
+	feed := m.feeds[feedIndex]
+	return func() tea.Msg {
+		if feed.all {
+			posts, err := m.s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
+				UserID: m.user.ID,
+				Limit:  tuiPostLimit,
+			})
+			if err != nil {
+				return errMsg{fmt.Errorf("error getting posts: %w", err)}
+			}
+			return postsLoadedMsg{feedIndex: feedIndex, posts: posts}
+		}
+
+		rows, err := m.s.db.GetPostsForUserFeed(context.Background(), database.GetPostsForUserFeedParams{
+			UserID: m.user.ID,
+			FeedID: feed.id,
+			Limit:  tuiPostLimit,
+		})
+		if err != nil {
+			return errMsg{fmt.Errorf("error getting posts for %s: %w", feed.name, err)}
+		}
+
+		posts := make([]database.GetPostsForUserRow, len(rows))
+		for i, row := range rows {
+			posts[i] = database.GetPostsForUserRow(row)
+		}
+		return postsLoadedMsg{feedIndex: feedIndex, posts: posts}
+	}
+}
+
+func (m tuiModel) setRead(post database.GetPostsForUserRow, read bool) tea.Cmd {
+	return func() tea.Msg {
+		if !read {
+			err := m.s.db.MarkPostUnread(context.Background(), database.MarkPostUnreadParams{
+				UserID: m.user.ID,
+				PostID: post.ID,
+			})
+			if err != nil {
+				return errMsg{fmt.Errorf("error marking post unread: %w", err)}
+			}
+			return postStateMsg{postID: post.ID}
+		}
+
+		err := m.s.db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+			UserID: m.user.ID,
+			PostID: post.ID,
+		})
+		if err != nil {
+			return errMsg{fmt.Errorf("error marking post read: %w", err)}
+		}
+		return postStateMsg{postID: post.ID, readAt: sql.NullTime{Time: time.Now(), Valid: true}}
+	}
+}
+
+func (m tuiModel) openPost(post database.GetPostsForUserRow) tea.Cmd {
+	return tea.Batch(
+		func() tea.Msg {
+			if err := openBrowser(m.s.cfg.Browser, post.Url); err != nil {
+				return errMsg{err}
+			}
+			return statusMsg("Opened " + htmltext.Line(post.Url))
+		},
+		m.setRead(post, true),
+	)
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case feedsLoadedMsg:
+		m.feeds = msg.feeds
+		m.feedCursor = 0
+		m.status = fmt.Sprintf("%d feeds", len(m.feeds)-1)
+		return m, m.loadPosts(0)
+
+	case postsLoadedMsg:
+		// Ignore results for a feed the cursor has already moved away from.
+		if msg.feedIndex != m.feedCursor {
+			return m, nil
+		}
+		m.posts = msg.posts
+		m.postCursor = 0
+		m.previewOffset = 0
+		return m, nil
+
+	case postStateMsg:
+		for i := range m.posts {
+			if m.posts[i].ID == msg.postID {
+				m.posts[i].ReadAt = msg.readAt
+			}
+		}
+		return m, nil
+
+	case statusMsg:
+		m.status = string(msg)
+		return m, nil
+
+	case errMsg:
+		m.status = "Error: " + msg.err.Error()
+		return m, nil
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m tuiModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+
+	case "tab", "l", "right":
+		if m.focus < panePreview {
+			m.focus++
+		}
+
+	case "shift+tab", "h", "left":
+		if m.focus > paneFeeds {
+			m.focus--
+		}
+
+	case "j", "down":
+		return m.moveCursor(1)
+
+	case "k", "up":
+		return m.moveCursor(-1)
+
+	case "enter":
+		switch m.focus {
+		case paneFeeds:
+			m.focus = panePosts
+		case panePosts:
+			if post, ok := m.selectedPost(); ok {
+				m.focus = panePreview
+				m.previewOffset = 0
+				return m, m.setRead(post, true)
+			}
+		}
+
+	case "r":
+		if post, ok := m.selectedPost(); ok {
+			return m, m.setRead(post, !post.ReadAt.Valid)
+		}
+
+	case "o":
+		if post, ok := m.selectedPost(); ok {
+			return m, m.openPost(post)
+		}
+	}
+
+	return m, nil
+}
+
+func (m tuiModel) moveCursor(delta int) (tea.Model, tea.Cmd) {
+	switch m.focus {
+	case paneFeeds:
+		next := clamp(m.feedCursor+delta, 0, len(m.feeds)-1)
+		if next == m.feedCursor {
+			return m, nil
+		}
+		m.feedCursor = next
+		m.posts = nil
+		return m, m.loadPosts(next)
+	case panePosts:
+		next := clamp(m.postCursor+delta, 0, len(m.posts)-1)
+		if next != m.postCursor {
+			m.postCursor = next
+			m.previewOffset = 0
+		}
+	case panePreview:
+		_, _, previewWidth, innerHeight := m.layout()
+		lines := m.previewLines(previewWidth - 2)
+		m.previewOffset = clamp(m.previewOffset+delta, 0, len(lines)-innerHeight)
+	}
+	return m, nil
+}
+
+func (m tuiModel) selectedPost() (database.GetPostsForUserRow, bool) {
+	if m.postCursor < 0 || m.postCursor >= len(m.posts) {
+		return database.GetPostsForUserRow{}, false
+	}
+	return m.posts[m.postCursor], true
+}
+
+// ===== Rendering =====
+
+var (
+	paneStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("240"))
+	focusedPaneStyle = paneStyle.BorderForeground(lipgloss.Color("42"))
+	selectedStyle    = lipgloss.NewStyle().Reverse(true)
+	titleStyle       = lipgloss.NewStyle().Bold(true)
+	dimStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+)
+
+const tuiHelp = "j/k move • h/l switch pane • enter select • r toggle read • o open • q quit"
+
+func (m tuiModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return m.status
+	}
+
+	feedsWidth, postsWidth, previewWidth, innerHeight := m.layout()
+
+	feedLines := make([]string, len(m.feeds))
+	for i, feed := range m.feeds {
+		feedLines[i] = feed.name
+	}
+
+	postLines := make([]string, len(m.posts))
+	for i, post := range m.posts {
+		marker := "● "
+		if post.ReadAt.Valid {
+			marker = "  "
+		}
+		postLines[i] = marker + htmltext.Line(post.Title)
+	}
+
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		m.pane(paneFeeds, feedsWidth, innerHeight, renderList(feedLines, m.feedCursor, feedsWidth-2, innerHeight)),
+		m.pane(panePosts, postsWidth, innerHeight, renderList(postLines, m.postCursor, postsWidth-2, innerHeight)),
+		m.pane(panePreview, previewWidth, innerHeight, m.renderPreview(previewWidth-2, innerHeight)),
+	)
+
+	status := dimStyle.Render(ansi.Truncate(m.status+" | "+tuiHelp, m.width, "…"))
+	return lipgloss.JoinVertical(lipgloss.Left, body, status)
+}
+
+// layout returns the outer width of each pane and the inner height shared by
+// all of them, leaving one line for the status bar and room for borders.
+func (m tuiModel) layout() (feedsWidth, postsWidth, previewWidth, innerHeight int) {
+	feedsWidth = m.width / 5
+	postsWidth = m.width * 3 / 10
+	previewWidth = m.width - feedsWidth - postsWidth
+	innerHeight = max(m.height-3, 1)
+	return feedsWidth, postsWidth, previewWidth, innerHeight
+}
+
+func (m tuiModel) pane(p tuiPane, width, height int, content string) string {
+	style := paneStyle
+	if m.focus == p {
+		style = focusedPaneStyle
+	}
+	return style.Width(max(width-2, 1)).Height(height).MaxHeight(height + 2).Render(content)
+}
+
+// renderList renders the slice of lines visible around cursor, highlighting
+// the selected entry.
+func renderList(lines []string, cursor, width, height int) string {
+	start := 0
+	if cursor >= height {
+		start = cursor - height + 1
+	}
+	end := min(start+height, len(lines))
+
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		line := ansi.Truncate(lines[i], max(width, 1), "…")
+		if i == cursor {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line)
+		if i < end-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func (m tuiModel) renderPreview(width, height int) string {
+	lines := m.previewLines(width)
+	if lines == nil {
+		return dimStyle.Render("No post selected")
+	}
+
+	offset := min(m.previewOffset, max(len(lines)-height, 0))
+	end := min(offset+height, len(lines))
+	return strings.Join(lines[offset:end], "\n")
+}
+
+func (m tuiModel) previewLines(width int) []string {
+	post, ok := m.selectedPost()
+	if !ok {
+		return nil
+	}
+
+	body := htmltext.Render(post.Description.String, width)
+	if post.Content.Valid {
+		body = htmltext.Wrap(post.Content.String, width)
+	}
+
+	wrap := lipgloss.NewStyle().Width(max(width, 1))
+	header := []string{
+		wrap.Inherit(titleStyle).Render(htmltext.Line(post.Title)),
+		dimStyle.Render(fmt.Sprintf("[%s] %s • %s", shortID(post.ID), htmltext.Line(post.FeedName), post.PublishedAt.Time.Format("Mon Jan 2 2006"))),
+		dimStyle.Render(ansi.Truncate(htmltext.Line(post.Url), max(width, 1), "…")),
+		"",
+		body,
+	}
+	return strings.Split(strings.Join(header, "\n"), "\n")
+}
+
+func clamp(v, lo, hi int) int {
+	if hi < lo {
+		return lo
+	}
+	return min(max(v, lo), hi)
+}